@@ -0,0 +1,372 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultBreakerFailureRatio = 0.5
+	defaultBreakerMinRequests  = 10
+	defaultBreakerWindow       = 30 * time.Second
+	defaultBreakerOpenTimeout  = 30 * time.Second
+)
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets all calls through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails all calls with ErrCircuitOpen
+	CircuitOpen
+	// CircuitHalfOpen lets one probe call through to find out whether Secret Manager recovered
+	CircuitHalfOpen
+)
+
+// String returns the name of the state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerOption configures a CircuitBreaker
+type BreakerOption func(*CircuitBreaker)
+
+// BreakerFailureRatio sets the share of failed calls within a window that opens the circuit. The default is 0.5
+func BreakerFailureRatio(ratio float64) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.failureRatio = ratio
+	}
+}
+
+// BreakerMinRequests sets how many calls a window needs before the failure ratio is considered. The default is 10
+func BreakerMinRequests(n int) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.minRequests = n
+	}
+}
+
+// BreakerWindow sets how long calls are counted before the counts start over. The default is 30 seconds
+func BreakerWindow(d time.Duration) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.window = d
+	}
+}
+
+// BreakerOpenTimeout sets how long the circuit stays open before a probe call is let through. The default is 30 seconds
+func BreakerOpenTimeout(d time.Duration) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.openTimeout = d
+	}
+}
+
+// BreakerOnStateChange sets a function called whenever the circuit changes state
+func BreakerOnStateChange(fn func(from CircuitState, to CircuitState)) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.onStateChange = fn
+	}
+}
+
+// WithCircuitBreaker wraps the SecretClient of the Client in a CircuitBreaker, see Client.CircuitState
+func WithCircuitBreaker(opts ...BreakerOption) Option {
+	return func(c *Client) {
+		c.breakerOpts = append(c.breakerOpts, opts...)
+		c.useBreaker = true
+	}
+}
+
+// CircuitState returns the state of the circuit breaker of the Client, CircuitClosed if it has none
+func (c *Client) CircuitState() CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	return c.breaker.State()
+}
+
+// CircuitBreaker is a SecretClient that stops calling another SecretClient while too many of its calls fail
+// because Secret Manager is unavailable, failing fast with ErrCircuitOpen instead.
+// Errors that say nothing about the health of Secret Manager, such as NotFound, do not count as failures
+type CircuitBreaker struct {
+	next          SecretClient
+	failureRatio  float64
+	minRequests   int
+	window        time.Duration
+	openTimeout   time.Duration
+	onStateChange func(from CircuitState, to CircuitState)
+	now           func() time.Time
+
+	mu          sync.Mutex
+	state       CircuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	changes     []stateChange
+}
+
+// stateChange is a transition of the circuit not yet reported to onStateChange
+type stateChange struct {
+	from CircuitState
+	to   CircuitState
+}
+
+// NewCircuitBreaker returns a CircuitBreaker in front of next
+func NewCircuitBreaker(next SecretClient, opts ...BreakerOption) *CircuitBreaker {
+	b := &CircuitBreaker{
+		next:         next,
+		failureRatio: defaultBreakerFailureRatio,
+		minRequests:  defaultBreakerMinRequests,
+		window:       defaultBreakerWindow,
+		openTimeout:  defaultBreakerOpenTimeout,
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// State returns the current state of the circuit
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && !b.now().Before(b.openedAt.Add(b.openTimeout)) {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// circuitOpenError is returned while the circuit is open. It has the Unavailable code, so callers treat it like
+// an outage, and matches ErrCircuitOpen
+type circuitOpenError struct{}
+
+func (circuitOpenError) Error() string { return ErrCircuitOpen.Error() }
+
+func (circuitOpenError) Is(target error) bool { return target == ErrCircuitOpen }
+
+func (circuitOpenError) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, ErrCircuitOpen.Error())
+}
+
+// allow reports whether a call may be made and whether it is the probe of a half-open circuit
+func (b *CircuitBreaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Before(b.openedAt.Add(b.openTimeout)) {
+			return false, circuitOpenError{}
+		}
+		b.setState(CircuitHalfOpen)
+		fallthrough
+	case CircuitHalfOpen:
+		if b.probing {
+			return false, circuitOpenError{}
+		}
+		b.probing = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// done records the outcome of a call
+func (b *CircuitBreaker) done(probe bool, err error) {
+	failed := breakerFailure(err)
+
+	b.mu.Lock()
+	defer b.unlock()
+
+	now := b.now()
+	if probe {
+		b.probing = false
+		if errors.Is(err, context.Canceled) {
+			// a cancelled probe says nothing about Secret Manager, the next call probes again
+			return
+		}
+		if failed {
+			b.open(now)
+		} else {
+			b.setState(CircuitClosed)
+			b.windowStart, b.requests, b.failures = now, 0, 0
+		}
+		return
+	}
+	if b.state != CircuitClosed {
+		return
+	}
+
+	if now.Sub(b.windowStart) >= b.window {
+		b.windowStart, b.requests, b.failures = now, 0, 0
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+	if b.requests >= b.minRequests && float64(b.failures) >= b.failureRatio*float64(b.requests) {
+		b.open(now)
+	}
+}
+
+func (b *CircuitBreaker) open(now time.Time) {
+	b.openedAt = now
+	b.setState(CircuitOpen)
+}
+
+// setState changes the state, which is reported to onStateChange by unlock
+func (b *CircuitBreaker) setState(state CircuitState) {
+	if b.state == state {
+		return
+	}
+	b.changes = append(b.changes, stateChange{from: b.state, to: state})
+	b.state = state
+}
+
+// unlock releases mu, then reports the state changes made while it was held,
+// so onStateChange may call State
+func (b *CircuitBreaker) unlock() {
+	changes := b.changes
+	b.changes = nil
+	b.mu.Unlock()
+
+	if b.onStateChange == nil {
+		return
+	}
+	for _, change := range changes {
+		b.onStateChange(change.from, change.to)
+	}
+}
+
+// breakerFailure reports whether err means Secret Manager is unhealthy
+func breakerFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
+
+// breakerCall makes a call through the circuit breaker
+func breakerCall[T any](b *CircuitBreaker, fn func() (T, error)) (T, error) {
+	probe, err := b.allow()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	result, err := fn()
+	b.done(probe, err)
+	return result, err
+}
+
+// AccessSecretVersion calls AccessSecretVersion unless the circuit is open
+func (b *CircuitBreaker) AccessSecretVersion(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+	return breakerCall(b, func() (*pb.AccessSecretVersionResponse, error) { return b.next.AccessSecretVersion(ctx, req) })
+}
+
+// DestroySecretVersion calls DestroySecretVersion unless the circuit is open
+func (b *CircuitBreaker) DestroySecretVersion(ctx context.Context, req *pb.DestroySecretVersionRequest) (*pb.SecretVersion, error) {
+	return breakerCall(b, func() (*pb.SecretVersion, error) { return b.next.DestroySecretVersion(ctx, req) })
+}
+
+// CreateSecret calls CreateSecret unless the circuit is open
+func (b *CircuitBreaker) CreateSecret(ctx context.Context, req *pb.CreateSecretRequest) (*pb.Secret, error) {
+	return breakerCall(b, func() (*pb.Secret, error) { return b.next.CreateSecret(ctx, req) })
+}
+
+// AddSecretVersion calls AddSecretVersion unless the circuit is open
+func (b *CircuitBreaker) AddSecretVersion(ctx context.Context, req *pb.AddSecretVersionRequest) (*pb.SecretVersion, error) {
+	return breakerCall(b, func() (*pb.SecretVersion, error) { return b.next.AddSecretVersion(ctx, req) })
+}
+
+// DeleteSecret calls DeleteSecret unless the circuit is open
+func (b *CircuitBreaker) DeleteSecret(ctx context.Context, req *pb.DeleteSecretRequest) error {
+	_, err := breakerCall(b, func() (struct{}, error) { return struct{}{}, b.next.DeleteSecret(ctx, req) })
+	return err
+}
+
+// GetSecret calls GetSecret unless the circuit is open
+func (b *CircuitBreaker) GetSecret(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+	return breakerCall(b, func() (*pb.Secret, error) { return b.next.GetSecret(ctx, req) })
+}
+
+// UpdateSecret calls UpdateSecret unless the circuit is open
+func (b *CircuitBreaker) UpdateSecret(ctx context.Context, req *pb.UpdateSecretRequest) (*pb.Secret, error) {
+	return breakerCall(b, func() (*pb.Secret, error) { return b.next.UpdateSecret(ctx, req) })
+}
+
+// GetSecretVersion calls GetSecretVersion unless the circuit is open
+func (b *CircuitBreaker) GetSecretVersion(ctx context.Context, req *pb.GetSecretVersionRequest) (*pb.SecretVersion, error) {
+	return breakerCall(b, func() (*pb.SecretVersion, error) { return b.next.GetSecretVersion(ctx, req) })
+}
+
+// DisableSecretVersion calls DisableSecretVersion unless the circuit is open
+func (b *CircuitBreaker) DisableSecretVersion(ctx context.Context, req *pb.DisableSecretVersionRequest) (*pb.SecretVersion, error) {
+	return breakerCall(b, func() (*pb.SecretVersion, error) { return b.next.DisableSecretVersion(ctx, req) })
+}
+
+// EnableSecretVersion calls EnableSecretVersion unless the circuit is open
+func (b *CircuitBreaker) EnableSecretVersion(ctx context.Context, req *pb.EnableSecretVersionRequest) (*pb.SecretVersion, error) {
+	return breakerCall(b, func() (*pb.SecretVersion, error) { return b.next.EnableSecretVersion(ctx, req) })
+}
+
+// ListSecrets calls ListSecrets unless the circuit is open
+func (b *CircuitBreaker) ListSecrets(ctx context.Context, req *pb.ListSecretsRequest) (*pb.ListSecretsResponse, error) {
+	return breakerCall(b, func() (*pb.ListSecretsResponse, error) { return b.next.ListSecrets(ctx, req) })
+}
+
+// ListSecretVersions calls ListSecretVersions unless the circuit is open
+func (b *CircuitBreaker) ListSecretVersions(ctx context.Context, req *pb.ListSecretVersionsRequest) (*pb.ListSecretVersionsResponse, error) {
+	return breakerCall(b, func() (*pb.ListSecretVersionsResponse, error) { return b.next.ListSecretVersions(ctx, req) })
+}
+
+// Close closes the wrapped SecretClient
+func (b *CircuitBreaker) Close() error {
+	return b.next.Close()
+}
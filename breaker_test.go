@@ -0,0 +1,215 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var transitions []string
+	b := NewCircuitBreaker(client,
+		BreakerFailureRatio(0.5),
+		BreakerMinRequests(4),
+		BreakerOpenTimeout(time.Minute),
+		BreakerOnStateChange(func(from CircuitState, to CircuitState) {
+			transitions = append(transitions, fmt.Sprintf("%v->%v", from, to))
+		}),
+	)
+	b.now = clock.Now
+
+	var results []error
+	calls := 0
+	GetSecretVersionFunc = func(ctx context.Context, req *pb.GetSecretVersionRequest) (*pb.SecretVersion, error) {
+		calls++
+		if len(results) == 0 {
+			return secretVersionPositiveReturn, nil
+		}
+		err := results[0]
+		results = results[1:]
+		return nil, err
+	}
+	get := func() error {
+		_, err := b.GetSecretVersion(context.Background(), &pb.GetSecretVersionRequest{})
+		return err
+	}
+
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	notFound := status.Error(codes.NotFound, "not found")
+
+	// NotFound is a healthy answer, so 1 of 4 calls failed
+	results = []error{unavailable, notFound, notFound}
+	for range 4 {
+		_ = get()
+	}
+	if b.State() != CircuitClosed {
+		t.Fatalf("State() = %v, want closed", b.State())
+	}
+
+	results = []error{unavailable, unavailable, unavailable}
+	for range 4 {
+		_ = get()
+	}
+	if b.State() != CircuitOpen {
+		t.Fatalf("State() = %v, want open", b.State())
+	}
+
+	calls = 0
+	err := get()
+	if !errors.Is(err, ErrCircuitOpen) || status.Code(err) != codes.Unavailable {
+		t.Errorf("error = %v, want %v with code Unavailable", err, ErrCircuitOpen)
+	}
+	if calls != 0 {
+		t.Errorf("open circuit made %v calls", calls)
+	}
+
+	// after the open timeout one probe is let through; it fails and the circuit opens again
+	clock.Add(time.Minute)
+	if b.State() != CircuitHalfOpen {
+		t.Fatalf("State() = %v, want half-open", b.State())
+	}
+	results = []error{unavailable}
+	if err := get(); !errors.Is(err, unavailable) {
+		t.Errorf("probe error = %v, want %v", err, unavailable)
+	}
+	if b.State() != CircuitOpen {
+		t.Fatalf("State() = %v, want open after a failed probe", b.State())
+	}
+
+	// a cancelled probe leaves the circuit half-open, and the next call probes again
+	clock.Add(time.Minute)
+	results = []error{context.Canceled}
+	if err := get(); !errors.Is(err, context.Canceled) {
+		t.Errorf("probe error = %v, want %v", err, context.Canceled)
+	}
+	if b.State() != CircuitHalfOpen {
+		t.Fatalf("State() = %v, want half-open after a cancelled probe", b.State())
+	}
+
+	// only one probe at a time; a successful probe closes the circuit
+	GetSecretVersionFunc = func(ctx context.Context, req *pb.GetSecretVersionRequest) (*pb.SecretVersion, error) {
+		if err := get(); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("call during probe error = %v, want %v", err, ErrCircuitOpen)
+		}
+		return secretVersionPositiveReturn, nil
+	}
+	if err := get(); err != nil {
+		t.Errorf("probe error = %v", err)
+	}
+	if b.State() != CircuitClosed {
+		t.Errorf("State() = %v, want closed after a successful probe", b.State())
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if !reflect.DeepEqual(transitions, want) {
+		t.Errorf("transitions = %v, want %v", transitions, want)
+	}
+}
+
+func TestCircuitBreaker_StateInCallback(t *testing.T) {
+	var c *Client
+	var states []CircuitState
+	c, err := NewClient(context.Background(), WithSecretClient(client), WithProjectID("myProject"), WithCircuitBreaker(
+		BreakerMinRequests(1),
+		BreakerOnStateChange(func(from CircuitState, to CircuitState) {
+			states = append(states, c.CircuitState())
+		}),
+	))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	GetSecretFunc = func(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = c.LookupSecret(context.Background(), SecretName{Secret: "mySecret"})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("LookupSecret() did not return, the state change callback deadlocked")
+	}
+
+	if want := []CircuitState{CircuitOpen}; !reflect.DeepEqual(states, want) {
+		t.Errorf("callback read states %v, want %v", states, want)
+	}
+}
+
+func TestClient_CircuitBreakerServesStale(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c, err := NewClient(context.Background(),
+		WithSecretClient(client),
+		WithProjectID("myProject"),
+		WithCircuitBreaker(BreakerMinRequests(1)),
+		WithCache(CacheTTL(time.Minute), CacheMaxStaleness(time.Hour)),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	c.cache.now = clock.Now
+
+	var accessErr error
+	AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+		if accessErr != nil {
+			return nil, accessErr
+		}
+		return &pb.AccessSecretVersionResponse{Name: req.Name, Payload: &pb.SecretPayload{Data: []byte("data")}}, nil
+	}
+
+	if _, err := c.GetSecret(context.Background(), "mySecret", "", ""); err != nil {
+		t.Fatalf("GetSecret() error = %v", err)
+	}
+	accessErr = status.Error(codes.Unavailable, "unavailable")
+	if _, err := c.GetSecret(context.Background(), "otherSecret", "", ""); err == nil {
+		t.Fatalf("GetSecret() succeeded, want an error")
+	}
+	if c.CircuitState() != CircuitOpen {
+		t.Fatalf("CircuitState() = %v, want open", c.CircuitState())
+	}
+
+	clock.Add(2 * time.Minute)
+	payload, err := c.GetSecret(context.Background(), "mySecret", "", "")
+	if err != nil || string(payload.Data) != "data" {
+		t.Errorf("GetSecret() = %v, %v, want the stale data", payload, err)
+	}
+	if _, err := c.GetSecret(context.Background(), "otherSecret", "", ""); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("GetSecret() error = %v, want %v", err, ErrCircuitOpen)
+	}
+}
@@ -0,0 +1,306 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultCacheTTL        = time.Minute
+	defaultCachePinnedTTL  = time.Hour
+	defaultCacheMaxEntries = 1000
+)
+
+// CacheOption configures the cache enabled by WithCache
+type CacheOption func(*cache)
+
+// CacheTTL sets how long the data of latest and aliased versions is cached. The default is a minute
+func CacheTTL(ttl time.Duration) CacheOption {
+	return func(c *cache) {
+		c.ttl = ttl
+	}
+}
+
+// CachePinnedTTL sets how long the data of numbered versions is cached. Their data never changes,
+// so this is usually longer than CacheTTL. The default is an hour
+func CachePinnedTTL(ttl time.Duration) CacheOption {
+	return func(c *cache) {
+		c.pinnedTTL = ttl
+	}
+}
+
+// CacheMaxEntries sets how many versions are cached. The least recently used version is evicted
+// when the cache is full. The default is 1000
+func CacheMaxEntries(n int) CacheOption {
+	return func(c *cache) {
+		c.maxEntries = n
+	}
+}
+
+// CacheRefreshAhead refreshes a cached version in the background when it is read within d of its expiry,
+// so reads keep being served from the cache. The default is no background refresh
+func CacheRefreshAhead(d time.Duration) CacheOption {
+	return func(c *cache) {
+		c.refreshAhead = d
+	}
+}
+
+// CacheMaxStaleness keeps serving a version for up to d after it expired when Secret Manager cannot be
+// reached to refresh it. Errors such as NotFound or PermissionDenied are returned as usual.
+// The default is to never serve expired versions
+func CacheMaxStaleness(d time.Duration) CacheOption {
+	return func(c *cache) {
+		c.maxStaleness = d
+	}
+}
+
+// CacheOnStale sets a function called whenever an expired version is served, with how long ago it expired
+// and the error that prevented refreshing it
+func CacheOnStale(fn func(name VersionName, staleness time.Duration, err error)) CacheOption {
+	return func(c *cache) {
+		c.onStale = fn
+	}
+}
+
+// WithCache caches the data read by AccessSecretVersion and GetSecret in memory. Versions changed or deleted
+// through the Client are evicted; changes made elsewhere are seen once the cached entry expires
+func WithCache(opts ...CacheOption) Option {
+	return func(c *Client) {
+		c.cache = newCache(opts)
+	}
+}
+
+// cache is an LRU cache of version data keyed by version resource name
+type cache struct {
+	ttl          time.Duration
+	pinnedTTL    time.Duration
+	maxEntries   int
+	refreshAhead time.Duration
+	maxStaleness time.Duration
+	onStale      func(name VersionName, staleness time.Duration, err error)
+	now          func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key        string
+	result     *pb.AccessSecretVersionResponse
+	expires    time.Time
+	refreshing bool
+}
+
+// cacheState tells how a cached version may be used
+type cacheState int
+
+const (
+	// cacheMiss means the version is not cached
+	cacheMiss cacheState = iota
+	// cacheFresh means the version can be served
+	cacheFresh
+	// cacheRefresh means the version can be served and should be refreshed in the background
+	cacheRefresh
+	// cacheStale means the version expired and may only be served if it cannot be refreshed
+	cacheStale
+)
+
+func newCache(opts []CacheOption) *cache {
+	c := &cache{
+		ttl:        defaultCacheTTL,
+		pinnedTTL:  defaultCachePinnedTTL,
+		maxEntries: defaultCacheMaxEntries,
+		now:        time.Now,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// get returns a copy of the cached data of a version and how it may be used.
+// For stale versions it also returns how long ago they expired
+func (c *cache) get(name VersionName) (*pb.AccessSecretVersionResponse, cacheState, time.Duration) {
+	if c == nil {
+		return nil, cacheMiss, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[name.String()]
+	if !ok {
+		return nil, cacheMiss, 0
+	}
+	entry := elem.Value.(*cacheEntry)
+	staleness := c.now().Sub(entry.expires)
+	if staleness >= 0 && staleness >= c.maxStaleness {
+		c.remove(elem)
+		return nil, cacheMiss, 0
+	}
+	c.lru.MoveToFront(elem)
+	result := proto.Clone(entry.result).(*pb.AccessSecretVersionResponse)
+
+	switch {
+	case staleness >= 0:
+		return result, cacheStale, staleness
+	case c.refreshAhead > 0 && -staleness <= c.refreshAhead && !entry.refreshing:
+		entry.refreshing = true
+		return result, cacheRefresh, 0
+	}
+	return result, cacheFresh, 0
+}
+
+// refreshFailed allows another background refresh of a version after one failed
+func (c *cache) refreshFailed(name VersionName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[name.String()]; ok {
+		elem.Value.(*cacheEntry).refreshing = false
+	}
+}
+
+// stale reports that an expired version is served
+func (c *cache) stale(name VersionName, staleness time.Duration, err error) {
+	if c.onStale != nil {
+		c.onStale(name, staleness, err)
+	}
+}
+
+// put caches a copy of the data of a version
+func (c *cache) put(name VersionName, result *pb.AccessSecretVersionResponse) {
+	if c == nil || c.maxEntries <= 0 {
+		return
+	}
+	ttl := c.ttl
+	if _, pinned := name.Number(); pinned {
+		ttl = c.pinnedTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := name.String()
+	entry := &cacheEntry{key: key, result: proto.Clone(result).(*pb.AccessSecretVersionResponse), expires: c.now().Add(ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// servesStale reports whether a stale version may be served after refreshing it failed with err.
+// Only errors that say nothing about the version itself qualify
+func servesStale(err error) bool {
+	var secretErr *SecretError
+	if !errors.As(err, &secretErr) {
+		return false
+	}
+	switch secretErr.Code {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// invalidate evicts all cached versions of a secret
+func (c *cache) invalidate(name SecretName) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prefix := name.String() + "/versions/"
+	for key, elem := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(elem)
+		}
+	}
+}
+
+func (c *cache) purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.Init()
+	clear(c.entries)
+}
+
+func (c *cache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+}
+
+// refresh reads a cached version again in the background, without the cancellation of the read that triggered it
+func (c *Client) refresh(ctx context.Context, name VersionName) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, err := c.accessSecretVersion(context.WithoutCancel(ctx), name); err != nil {
+		c.cache.refreshFailed(name)
+	}
+}
+
+// Invalidate evicts all cached versions of a secret, so the next read gets them from Secret Manager.
+// An empty Project means the default project
+func (c *Client) Invalidate(ctx context.Context, name SecretName) error {
+	name, err := c.secretName(ctx, name)
+	if err != nil {
+		return err
+	}
+	c.cache.invalidate(name)
+	return nil
+}
+
+// Purge evicts all cached versions
+func (c *Client) Purge() {
+	c.cache.purge()
+}
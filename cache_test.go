@@ -0,0 +1,265 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// countAccesses makes AccessSecretVersion return the requested name as data and counts the calls per name
+func countAccesses() map[string]int {
+	calls := make(map[string]int)
+	AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+		calls[req.Name]++
+		return &pb.AccessSecretVersionResponse{Name: req.Name, Payload: &pb.SecretPayload{Data: []byte(req.Name)}}, nil
+	}
+	return calls
+}
+
+func TestClient_Cache(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &Client{smc: client, projectID: "myProject", cache: newCache([]CacheOption{CacheTTL(time.Minute), CachePinnedTTL(time.Hour)})}
+	c.cache.now = func() time.Time { return now }
+	calls := countAccesses()
+
+	read := func(version string) {
+		t.Helper()
+		payload, err := c.GetSecret(context.Background(), "mySecret", "", version)
+		if err != nil {
+			t.Fatalf("GetSecret() error = %v", err)
+		}
+		if want := "projects/myProject/secrets/mySecret/versions/" + version; string(payload.Data) != want {
+			t.Errorf("GetSecret() = %s, want %v", payload.Data, want)
+		}
+		// changing the result must not change the cache
+		payload.Data[0] = 'X'
+	}
+	const latest = "projects/myProject/secrets/mySecret/versions/latest"
+	const pinned = "projects/myProject/secrets/mySecret/versions/1"
+
+	read("latest")
+	read("latest")
+	read("1")
+	if calls[latest] != 1 || calls[pinned] != 1 {
+		t.Errorf("calls = %v, want one per version", calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	read("latest")
+	read("1")
+	if calls[latest] != 2 || calls[pinned] != 1 {
+		t.Errorf("calls = %v, want latest to expire before the pinned version", calls)
+	}
+
+	AddSecretVersionFunc = func(ctx context.Context, req *pb.AddSecretVersionRequest) (*pb.SecretVersion, error) {
+		return &pb.SecretVersion{Name: "projects/myProject/secrets/mySecret/versions/2"}, nil
+	}
+	if _, err := c.AddNewSecretVersion(context.Background(), "mySecret", "", []byte("new")); err != nil {
+		t.Fatalf("AddNewSecretVersion() error = %v", err)
+	}
+	read("latest")
+	if calls[latest] != 3 {
+		t.Errorf("calls = %v, want adding a version to evict latest", calls)
+	}
+
+	if err := c.Invalidate(context.Background(), SecretName{Secret: "mySecret"}); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	read("1")
+	if calls[pinned] != 2 {
+		t.Errorf("calls = %v, want Invalidate to evict all versions", calls)
+	}
+
+	c.Purge()
+	read("1")
+	if calls[pinned] != 3 {
+		t.Errorf("calls = %v, want Purge to evict all versions", calls)
+	}
+}
+
+func TestClient_CacheEviction(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject", cache: newCache([]CacheOption{CacheMaxEntries(2)})}
+	calls := countAccesses()
+
+	for _, version := range []string{"1", "2", "1", "3", "1", "2"} {
+		if _, err := c.GetSecret(context.Background(), "mySecret", "", version); err != nil {
+			t.Fatalf("GetSecret() error = %v", err)
+		}
+	}
+	// 2 is the least recently used version when 3 is added
+	want := map[string]int{
+		"projects/myProject/secrets/mySecret/versions/1": 1,
+		"projects/myProject/secrets/mySecret/versions/2": 2,
+		"projects/myProject/secrets/mySecret/versions/3": 1,
+	}
+	for name, n := range want {
+		if calls[name] != n {
+			t.Errorf("calls = %v, want %v", calls, want)
+			break
+		}
+	}
+}
+
+func TestClient_NoCache(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+	calls := countAccesses()
+
+	for range 2 {
+		if _, err := c.GetSecret(context.Background(), "mySecret", "", "1"); err != nil {
+			t.Fatalf("GetSecret() error = %v", err)
+		}
+	}
+	if n := calls["projects/myProject/secrets/mySecret/versions/1"]; n != 2 {
+		t.Errorf("calls = %v, want 2 without a cache", n)
+	}
+	c.Purge()
+}
+
+// fakeClock is a time source for the cache that tests move forward
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Add(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func TestClient_CacheRefreshAhead(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := &Client{smc: client, projectID: "myProject", cache: newCache([]CacheOption{CacheTTL(time.Minute), CacheRefreshAhead(10 * time.Second)})}
+	c.cache.now = clock.Now
+
+	accesses := make(chan struct{}, 10)
+	AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+		accesses <- struct{}{}
+		return &pb.AccessSecretVersionResponse{Name: req.Name, Payload: &pb.SecretPayload{Data: []byte("data")}}, nil
+	}
+	read := func() {
+		t.Helper()
+		if _, err := c.GetSecret(context.Background(), "mySecret", "", ""); err != nil {
+			t.Fatalf("GetSecret() error = %v", err)
+		}
+	}
+
+	read()
+	<-accesses
+
+	// close to expiry the cached data is served and refreshed in the background, once
+	clock.Add(55 * time.Second)
+	read()
+	read()
+	<-accesses
+
+	// wait for the refreshed data to be cached
+	for {
+		if _, state, _ := c.cache.get(SecretName{Project: "myProject", Secret: "mySecret"}.Version("")); state == cacheFresh {
+			break
+		}
+		runtime.Gosched()
+	}
+	clock.Add(40 * time.Second)
+	read()
+	select {
+	case <-accesses:
+		t.Errorf("refreshed data was not cached")
+	default:
+	}
+}
+
+func TestClient_CacheStale(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var stale []time.Duration
+	c := &Client{smc: client, projectID: "myProject", cache: newCache([]CacheOption{
+		CacheTTL(time.Minute),
+		CacheMaxStaleness(5 * time.Minute),
+		CacheOnStale(func(name VersionName, staleness time.Duration, err error) {
+			if name.Secret != "mySecret" || status.Code(errors.Unwrap(err)) != codes.Unavailable {
+				t.Errorf("stale %v, %v", name, err)
+			}
+			stale = append(stale, staleness)
+		}),
+	})}
+	c.cache.now = clock.Now
+
+	var accessErr error
+	AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+		if accessErr != nil {
+			return nil, accessErr
+		}
+		return &pb.AccessSecretVersionResponse{Name: req.Name, Payload: &pb.SecretPayload{Data: []byte("data")}}, nil
+	}
+	read := func() error {
+		payload, err := c.GetSecret(context.Background(), "mySecret", "", "")
+		if err == nil && string(payload.Data) != "data" {
+			t.Errorf("GetSecret() = %s, want data", payload.Data)
+		}
+		return err
+	}
+
+	if err := read(); err != nil {
+		t.Fatalf("GetSecret() error = %v", err)
+	}
+
+	accessErr = status.Error(codes.Unavailable, "backend unavailable")
+	clock.Add(2 * time.Minute)
+	if err := read(); err != nil {
+		t.Errorf("GetSecret() error = %v, want the stale data", err)
+	}
+	if !reflect.DeepEqual(stale, []time.Duration{time.Minute}) {
+		t.Errorf("staleness = %v, want [1m]", stale)
+	}
+
+	accessErr = status.Error(codes.PermissionDenied, "denied")
+	if err := read(); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("GetSecret() error = %v, want %v", err, ErrPermissionDenied)
+	}
+
+	accessErr = status.Error(codes.Unavailable, "backend unavailable")
+	clock.Add(5 * time.Minute)
+	if err := read(); err == nil {
+		t.Errorf("GetSecret() served data that is staler than the max staleness")
+	}
+}
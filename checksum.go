@@ -0,0 +1,68 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"hash/crc32"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// WithoutChecksums stops the Client from sending CRC32C checksums with new versions and verifying them on reads,
+// for endpoints that do not support them
+func WithoutChecksums() Option {
+	return func(c *Client) {
+		c.noChecksums = true
+	}
+}
+
+// crc32c returns the CRC32C checksum of data as Secret Manager represents it
+func crc32c(data []byte) *int64 {
+	return proto.Int64(int64(crc32.Checksum(data, crc32cTable)))
+}
+
+// setChecksum adds the checksum of its data to a payload that is about to be written
+func (c *Client) setChecksum(payload *pb.SecretPayload) {
+	if !c.noChecksums {
+		payload.DataCrc32C = crc32c(payload.Data)
+	}
+}
+
+// verifyChecksum checks the data of a payload that was read against its checksum, if it has one
+func (c *Client) verifyChecksum(op string, name VersionName, payload *pb.SecretPayload) error {
+	if c.noChecksums || payload == nil || payload.DataCrc32C == nil {
+		return nil
+	}
+	if *crc32c(payload.Data) != payload.GetDataCrc32C() {
+		return &SecretError{Op: op, Name: name.String(), Code: codes.DataLoss, Err: ErrChecksumMismatch}
+	}
+	return nil
+}
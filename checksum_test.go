@@ -0,0 +1,98 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestClient_AddSecretVersionChecksum(t *testing.T) {
+	checksumTest := func(opts []Option, want *int64) func(t *testing.T) {
+		return func(t *testing.T) {
+			c := &Client{smc: client, projectID: "myProject"}
+			for _, opt := range opts {
+				opt(c)
+			}
+
+			var got *pb.SecretPayload
+			AddSecretVersionFunc = func(ctx context.Context, req *pb.AddSecretVersionRequest) (*pb.SecretVersion, error) {
+				got = req.Payload
+				return secretVersionPositiveReturn, nil
+			}
+			if _, err := c.AddNewSecretVersion(context.Background(), "mySecret", "", []byte("hello")); err != nil {
+				t.Fatalf("AddNewSecretVersion() error = %v", err)
+			}
+			if (got.DataCrc32C == nil) != (want == nil) || want != nil && *got.DataCrc32C != *want {
+				t.Errorf("checksum = %v, want %v", got.DataCrc32C, want)
+			}
+		}
+	}
+
+	// CRC32C of "hello"
+	t.Run("Enabled", checksumTest(nil, proto.Int64(0x9a71bb4c)))
+	t.Run("Disabled", checksumTest([]Option{WithoutChecksums()}, nil))
+}
+
+func TestClient_AccessSecretVersionChecksum(t *testing.T) {
+	checksumTest := func(opts []Option, checksum *int64, wantErr error) func(t *testing.T) {
+		return func(t *testing.T) {
+			c := &Client{smc: client, projectID: "myProject", cache: newCache(nil)}
+			for _, opt := range opts {
+				opt(c)
+			}
+
+			calls := 0
+			AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+				calls++
+				return &pb.AccessSecretVersionResponse{Name: req.Name, Payload: &pb.SecretPayload{Data: []byte("hello"), DataCrc32C: checksum}}, nil
+			}
+
+			for range 2 {
+				payload, err := c.GetSecret(context.Background(), "mySecret", "", "1")
+				if !errors.Is(err, wantErr) {
+					t.Fatalf("GetSecret() error = %v, want %v", err, wantErr)
+				}
+				if err == nil && string(payload.Data) != "hello" {
+					t.Errorf("GetSecret() = %s, want hello", payload.Data)
+				}
+			}
+			if wantErr != nil && calls != 2 {
+				t.Errorf("corrupted data was cached")
+			}
+		}
+	}
+
+	t.Run("Valid", checksumTest(nil, proto.Int64(0x9a71bb4c), nil))
+	t.Run("Missing", checksumTest(nil, nil, nil))
+	t.Run("Mismatch", checksumTest(nil, proto.Int64(42), ErrChecksumMismatch))
+	t.Run("Disabled", checksumTest([]Option{WithoutChecksums()}, proto.Int64(42), nil))
+}
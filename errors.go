@@ -0,0 +1,135 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Errors returned by Client methods. Errors from Secret Manager are returned as a *SecretError,
+// which matches the sentinel for its gRPC code with errors.Is
+var (
+	ErrNotFound           = errors.New("gsm: not found")
+	ErrAlreadyExists      = errors.New("gsm: already exists")
+	ErrPermissionDenied   = errors.New("gsm: permission denied")
+	ErrFailedPrecondition = errors.New("gsm: failed precondition")
+	ErrVersionDisabled    = errors.New("gsm: secret version is disabled")
+	ErrVersionDestroyed   = errors.New("gsm: secret version is destroyed")
+	ErrInvalidName        = errors.New("gsm: invalid resource name")
+	ErrNoProject          = errors.New("gsm: no default project configured or detected")
+	ErrNothingToUpdate    = errors.New("gsm: no fields to update")
+	ErrInvalidOption      = errors.New("gsm: invalid secret option")
+	ErrChecksumMismatch   = errors.New("gsm: payload does not match its CRC32C checksum")
+	ErrTimeout            = errors.New("gsm: call timed out")
+	ErrCircuitOpen        = errors.New("gsm: circuit breaker is open")
+)
+
+// SecretError is returned when a Secret Manager call fails
+type SecretError struct {
+	// Op is the SecretClient method that failed, such as AccessSecretVersion
+	Op string
+	// Name is the resource name the call was made on
+	Name string
+	// Code is the gRPC code of the failure
+	Code codes.Code
+	// Err is the error returned by the SecretClient
+	Err error
+	// Attempts is how many times the call was made, more than 1 when it was retried
+	Attempts int
+}
+
+// Error returns the operation, resource name and cause of the failure
+func (e *SecretError) Error() string {
+	if e.Attempts > 1 {
+		return fmt.Sprintf("gsm: %v %v: %v (after %v attempts)", e.Op, e.Name, e.Err, e.Attempts)
+	}
+	return fmt.Sprintf("gsm: %v %v: %v", e.Op, e.Name, e.Err)
+}
+
+// Unwrap returns the error returned by the SecretClient
+func (e *SecretError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the sentinel error for the gRPC code of e
+func (e *SecretError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.Code == codes.NotFound
+	case ErrAlreadyExists:
+		return e.Code == codes.AlreadyExists
+	case ErrPermissionDenied:
+		return e.Code == codes.PermissionDenied
+	case ErrFailedPrecondition:
+		return e.Code == codes.FailedPrecondition
+	case ErrVersionDisabled:
+		return e.Code == codes.FailedPrecondition && e.versionState("DISABLED")
+	case ErrVersionDestroyed:
+		return e.Code == codes.FailedPrecondition && e.versionState("DESTROYED")
+	}
+	return false
+}
+
+// versionState reports whether Secret Manager rejected the call because the version is in state,
+// which it only tells in the error message
+func (e *SecretError) versionState(state string) bool {
+	return strings.Contains(strings.ToUpper(status.Convert(e.Err).Message()), state)
+}
+
+// wrapError returns err as a *SecretError for op on the resource name. Context errors get the matching gRPC code
+func wrapError(op string, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var secretErr *SecretError
+	if errors.As(err, &secretErr) {
+		return err
+	}
+	return &SecretError{Op: op, Name: name, Code: errorCode(err), Err: err}
+}
+
+// errorCode returns the gRPC code of err: the Code of a *SecretError, the code of a gRPC status
+// or the code matching a context error. It is OK for a nil err
+func errorCode(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	var secretErr *SecretError
+	if errors.As(err, &secretErr) {
+		return secretErr.Code
+	}
+	code := status.Code(err)
+	if code == codes.Unknown {
+		code = status.FromContextError(err).Code()
+	}
+	return code
+}
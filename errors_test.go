@@ -0,0 +1,110 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSecretError_Is(t *testing.T) {
+	isTest := func(err error, target error, want bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			secretErr := wrapError("AccessSecretVersion", "projects/myProject/secrets/mySecret/versions/1", err)
+			if got := errors.Is(secretErr, target); got != want {
+				t.Errorf("errors.Is(%v, %v) = %v, want %v", secretErr, target, got, want)
+			}
+		}
+	}
+
+	t.Run("NotFound", isTest(status.Error(codes.NotFound, "Secret not found"), ErrNotFound, true))
+	t.Run("AlreadyExists", isTest(status.Error(codes.AlreadyExists, "Secret already exists"), ErrAlreadyExists, true))
+	t.Run("PermissionDenied", isTest(status.Error(codes.PermissionDenied, "Permission denied"), ErrPermissionDenied, true))
+	t.Run("NotFoundIsNotPermissionDenied", isTest(status.Error(codes.NotFound, "Secret not found"), ErrPermissionDenied, false))
+	t.Run("FailedPrecondition", isTest(status.Error(codes.FailedPrecondition, "Secret is in an invalid state"), ErrFailedPrecondition, true))
+	t.Run("VersionDisabled", isTest(status.Error(codes.FailedPrecondition, "Secret Version [1] is in DISABLED state."), ErrVersionDisabled, true))
+	t.Run("VersionDisabledIsFailedPrecondition", isTest(status.Error(codes.FailedPrecondition, "Secret Version [1] is in DISABLED state."), ErrFailedPrecondition, true))
+	t.Run("VersionDestroyed", isTest(status.Error(codes.FailedPrecondition, "Secret Version [1] is in DESTROYED state."), ErrVersionDestroyed, true))
+	t.Run("DestroyedIsNotDisabled", isTest(status.Error(codes.FailedPrecondition, "Secret Version [1] is in DESTROYED state."), ErrVersionDisabled, false))
+	t.Run("Unknown", isTest(errors.New("connection reset"), ErrNotFound, false))
+}
+
+func TestClient_SecretError(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+
+	cause := status.Error(codes.PermissionDenied, "Permission denied on resource")
+	GetSecretVersionFunc = func(ctx context.Context, req *pb.GetSecretVersionRequest) (*pb.SecretVersion, error) {
+		return nil, cause
+	}
+
+	_, err := c.GetSecretMetadata(context.Background(), "mySecret", "", "2")
+	var secretErr *SecretError
+	if !errors.As(err, &secretErr) {
+		t.Fatalf("GetSecretMetadata() error = %T, want *SecretError", err)
+	}
+	want := SecretError{
+		Op:       "GetSecretVersion",
+		Name:     "projects/myProject/secrets/mySecret/versions/2",
+		Code:     codes.PermissionDenied,
+		Err:      cause,
+		Attempts: 1,
+	}
+	if *secretErr != want {
+		t.Errorf("GetSecretMetadata() error = %+v, want %+v", *secretErr, want)
+	}
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("errors.Is(%v, ErrPermissionDenied) = false", err)
+	}
+	if errors.Unwrap(err) != cause {
+		t.Errorf("Unwrap() = %v, want %v", errors.Unwrap(err), cause)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want codes.Code
+	}{
+		{nil, codes.OK},
+		{status.Error(codes.NotFound, "not found"), codes.NotFound},
+		{&SecretError{Code: codes.DataLoss, Err: ErrChecksumMismatch}, codes.DataLoss},
+		{fmt.Errorf("wrapped: %w", &SecretError{Code: codes.PermissionDenied}), codes.PermissionDenied},
+		{context.Canceled, codes.Canceled},
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+		{errors.New("other"), codes.Unknown},
+	} {
+		if got := errorCode(tt.err); got != tt.want {
+			t.Errorf("errorCode(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SecretTTL makes the secret and all its versions get deleted once ttl has passed.
+// On update the ttl counts from the time of the update
+func SecretTTL(ttl time.Duration) SecretOption {
+	return func(s *secretSpec) {
+		if ttl <= 0 {
+			s.fail(fmt.Errorf("%w: ttl must be positive, got %v", ErrInvalidOption, ttl))
+			return
+		}
+		s.secret.Expiration = &pb.Secret_Ttl{Ttl: durationpb.New(ttl)}
+		s.updateExpiration("ttl")
+	}
+}
+
+// SecretExpireTime makes the secret and all its versions get deleted at t
+func SecretExpireTime(t time.Time) SecretOption {
+	return func(s *secretSpec) {
+		if t.IsZero() {
+			s.fail(fmt.Errorf("%w: expire time must be set", ErrInvalidOption))
+			return
+		}
+		s.secret.Expiration = &pb.Secret_ExpireTime{ExpireTime: timestamppb.New(t)}
+		s.updateExpiration("expire_time")
+	}
+}
+
+// ClearSecretExpiration removes the expiration of the secret, so it is kept until deleted.
+// It only has an effect with UpdateSecret
+func ClearSecretExpiration() SecretOption {
+	return func(s *secretSpec) {
+		s.secret.Expiration = nil
+		s.updateExpiration("expire_time")
+	}
+}
+
+// updateExpiration records path in the update mask. ttl and expire_time are one field in Secret Manager,
+// so only the path of the last expiration option is kept
+func (s *secretSpec) updateExpiration(path string) {
+	paths := s.paths[:0]
+	for _, p := range s.paths {
+		if p != "ttl" && p != "expire_time" {
+			paths = append(paths, p)
+		}
+	}
+	s.paths = paths
+	s.update(path)
+}
+
+// ExpiringSecrets returns the secrets of project that expire within the given window from now, soonest first.
+// An empty project means the default project. Secrets without an expiration are skipped
+func (c *Client) ExpiringSecrets(ctx context.Context, project string, within time.Duration, opts ...ListOption) ([]*pb.Secret, error) {
+	deadline := time.Now().Add(within)
+
+	var expiring []*pb.Secret
+	it := c.ListSecrets(ctx, project, opts...)
+	for {
+		secret, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if expireTime := secret.GetExpireTime(); expireTime != nil && !expireTime.AsTime().After(deadline) {
+			expiring = append(expiring, secret)
+		}
+	}
+
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].GetExpireTime().AsTime().Before(expiring[j].GetExpireTime().AsTime())
+	})
+	return expiring, nil
+}
@@ -0,0 +1,131 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestClient_CreateSecretWithDataTTL(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+
+	var got *pb.CreateSecretRequest
+	CreateSecretFunc = func(ctx context.Context, req *pb.CreateSecretRequest) (*pb.Secret, error) {
+		got = req
+		return req.Secret, nil
+	}
+	AddSecretVersionFunc = func(ctx context.Context, req *pb.AddSecretVersionRequest) (*pb.SecretVersion, error) {
+		return secretVersionPositiveReturn, nil
+	}
+
+	if _, err := c.CreateSecretWithData(context.Background(), "ciToken", []byte("token"), "", SecretTTL(time.Hour)); err != nil {
+		t.Fatalf("CreateSecretWithData() error = %v", err)
+	}
+	if ttl := got.Secret.GetTtl().AsDuration(); ttl != time.Hour {
+		t.Errorf("CreateSecretWithData() ttl = %v, want %v", ttl, time.Hour)
+	}
+
+	if _, err := c.CreateSecret(context.Background(), SecretName{Secret: "ciToken"}, SecretTTL(-time.Hour)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("CreateSecret() error = %v, want %v", err, ErrInvalidOption)
+	}
+}
+
+func TestClient_UpdateSecretExpiration(t *testing.T) {
+	expireTime := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	expirationTest := func(opts []SecretOption, wantPaths []string, want func(secret *pb.Secret) bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			c := &Client{smc: client, projectID: "myProject"}
+
+			var got *pb.UpdateSecretRequest
+			UpdateSecretFunc = func(ctx context.Context, req *pb.UpdateSecretRequest) (*pb.Secret, error) {
+				got = req
+				return req.Secret, nil
+			}
+
+			if _, err := c.UpdateSecret(context.Background(), SecretName{Secret: "mySecret"}, opts...); err != nil {
+				t.Fatalf("UpdateSecret() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.UpdateMask.Paths, wantPaths) {
+				t.Errorf("UpdateSecret() mask = %v, want %v", got.UpdateMask.Paths, wantPaths)
+			}
+			if !want(got.Secret) {
+				t.Errorf("UpdateSecret() expiration = %v", got.Secret.Expiration)
+			}
+		}
+	}
+
+	t.Run("TTL", expirationTest([]SecretOption{SecretTTL(24 * time.Hour)}, []string{"ttl"}, func(secret *pb.Secret) bool {
+		return secret.GetTtl().AsDuration() == 24*time.Hour
+	}))
+
+	t.Run("ExpireTime", expirationTest([]SecretOption{SecretExpireTime(expireTime)}, []string{"expire_time"}, func(secret *pb.Secret) bool {
+		return secret.GetExpireTime().AsTime().Equal(expireTime)
+	}))
+
+	t.Run("LastWins", expirationTest([]SecretOption{SecretTTL(time.Hour), SecretLabels(map[string]string{"env": "ci"}), SecretExpireTime(expireTime)}, []string{"labels", "expire_time"}, func(secret *pb.Secret) bool {
+		return secret.GetExpireTime().AsTime().Equal(expireTime)
+	}))
+
+	t.Run("Clear", expirationTest([]SecretOption{ClearSecretExpiration()}, []string{"expire_time"}, func(secret *pb.Secret) bool {
+		return secret.Expiration == nil
+	}))
+}
+
+func TestClient_ExpiringSecrets(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+	now := time.Now()
+
+	expiring := func(name string, in time.Duration) *pb.Secret {
+		return &pb.Secret{Name: name, Expiration: &pb.Secret_ExpireTime{ExpireTime: timestamppb.New(now.Add(in))}}
+	}
+	ListSecretsFunc = func(ctx context.Context, req *pb.ListSecretsRequest) (*pb.ListSecretsResponse, error) {
+		if req.PageToken == "" {
+			return &pb.ListSecretsResponse{Secrets: []*pb.Secret{expiring("later", 30*24*time.Hour), expiring("tomorrow", 24*time.Hour)}, NextPageToken: "page2"}, nil
+		}
+		return &pb.ListSecretsResponse{Secrets: []*pb.Secret{{Name: "forever"}, expiring("soon", time.Hour)}}, nil
+	}
+
+	secrets, err := c.ExpiringSecrets(context.Background(), "", 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("ExpiringSecrets() error = %v", err)
+	}
+	var names []string
+	for _, secret := range secrets {
+		names = append(names, secret.Name)
+	}
+	if want := []string{"soon", "tomorrow"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ExpiringSecrets() = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,269 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+)
+
+// blockAccesses makes AccessSecretVersion wait for release and counts the calls.
+// started receives the context of every call
+func blockAccesses(release chan struct{}) (calls *atomic.Int32, started chan context.Context) {
+	calls = &atomic.Int32{}
+	started = make(chan context.Context, 10)
+	AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+		calls.Add(1)
+		started <- ctx
+		select {
+		case <-release:
+			return &pb.AccessSecretVersionResponse{Name: req.Name, Payload: &pb.SecretPayload{Data: []byte("data")}}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return calls, started
+}
+
+func TestClient_AccessCoalescing(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+	release := make(chan struct{})
+	calls, started := blockAccesses(release)
+
+	const readers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, readers)
+	for range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			payload, err := c.GetSecret(context.Background(), "mySecret", "", "")
+			if err == nil && string(payload.Data) != "data" {
+				err = errors.New("unexpected data " + string(payload.Data))
+			}
+			errs <- err
+		}()
+	}
+
+	<-started
+	// wait until every reader joined the call in flight
+	for {
+		c.accesses.mu.Lock()
+		waiters := 0
+		for _, call := range c.accesses.calls {
+			waiters += call.waiters
+		}
+		c.accesses.mu.Unlock()
+		if waiters == readers {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("GetSecret() error = %v", err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("AccessSecretVersion called %v times, want 1", n)
+	}
+}
+
+func TestClient_AccessCoalescingCancel(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+	release := make(chan struct{})
+	_, started := blockAccesses(release)
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, err := c.GetSecret(first, "mySecret", "", "")
+		firstErr <- err
+	}()
+	callCtx := <-started
+
+	second, cancelSecond := context.WithCancel(context.Background())
+	secondErr := make(chan error)
+	go func() {
+		_, err := c.GetSecret(second, "mySecret", "", "")
+		secondErr <- err
+	}()
+	for {
+		c.accesses.mu.Lock()
+		waiters := c.accesses.calls["projects/myProject/secrets/mySecret/versions/latest"].waiters
+		c.accesses.mu.Unlock()
+		if waiters == 2 {
+			break
+		}
+		runtime.Gosched()
+	}
+
+	// the caller that started the call gives up, the call keeps running for the other one
+	cancelFirst()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("GetSecret() error = %v, want %v", err, context.Canceled)
+	}
+	if callCtx.Err() != nil {
+		t.Fatalf("call cancelled while a caller is still waiting")
+	}
+	cancelSecond()
+	if err := <-secondErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("GetSecret() error = %v, want %v", err, context.Canceled)
+	}
+
+	// nobody is waiting any more, so the call is cancelled
+	<-callCtx.Done()
+}
+
+func TestClient_AccessCoalescingDeadline(t *testing.T) {
+	c, err := NewClient(context.Background(), WithSecretClient(client), WithProjectID("myProject"), WithCircuitBreaker(BreakerMinRequests(1)))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	// Secret Manager never responds
+	_, started := blockAccesses(make(chan struct{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	const readers = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, readers)
+	for range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetSecret(ctx, "mySecret", "", "")
+			errs <- err
+		}()
+	}
+
+	// the shared call keeps the deadline of the caller, so it times out instead of being cancelled
+	callCtx := <-started
+	if got, ok := callCtx.Deadline(); !ok || !got.Equal(deadline) {
+		t.Errorf("call deadline = %v, want %v", got, deadline)
+	}
+	<-callCtx.Done()
+	if !errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		t.Errorf("call error = %v, want %v", callCtx.Err(), context.DeadlineExceeded)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		var secretErr *SecretError
+		if !errors.As(err, &secretErr) || secretErr.Code != codes.DeadlineExceeded {
+			t.Errorf("GetSecret() error = %#v, want a *SecretError with code %v", err, codes.DeadlineExceeded)
+		}
+	}
+
+	// the timed out call counts as a failure of Secret Manager
+	for c.CircuitState() != CircuitOpen {
+		runtime.Gosched()
+	}
+}
+
+func TestFlightGroup_AbandonedCallKeepsNewCall(t *testing.T) {
+	var g flightGroup[*pb.SecretVersion]
+	var calls atomic.Int32
+	releases := []chan struct{}{make(chan struct{}), make(chan struct{})}
+	started := make(chan struct{}, 10)
+	fn := func(ctx context.Context) (*pb.SecretVersion, error) {
+		n := calls.Add(1)
+		started <- struct{}{}
+		<-releases[n-1]
+		return &pb.SecretVersion{Name: "v"}, nil
+	}
+	inFlight := func() *flightCall[*pb.SecretVersion] {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return g.calls["key"]
+	}
+
+	// the only caller of the first call gives up, so it is abandoned while still running
+	ctx, cancel := context.WithCancel(context.Background())
+	abandoned := make(chan error)
+	go func() {
+		_, err := g.do(ctx, "key", fn)
+		abandoned <- err
+	}()
+	<-started
+	first := inFlight()
+	cancel()
+	<-abandoned
+
+	// a new call is started for the key
+	results := make(chan error, 2)
+	go func() {
+		_, err := g.do(context.Background(), "key", fn)
+		results <- err
+	}()
+	<-started
+	second := inFlight()
+
+	// the abandoned call returns, the new call stays in flight and later callers join it
+	close(releases[0])
+	<-first.done
+	if got := inFlight(); got != second {
+		t.Fatalf("the abandoned call removed the call in flight")
+	}
+	go func() {
+		_, err := g.do(context.Background(), "key", fn)
+		results <- err
+	}()
+	for {
+		g.mu.Lock()
+		waiters := second.waiters
+		g.mu.Unlock()
+		if waiters == 2 {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(releases[1])
+	for range 2 {
+		if err := <-results; err != nil {
+			t.Errorf("do() error = %v", err)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("fn called %v times, want 2", n)
+	}
+}
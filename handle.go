@@ -0,0 +1,99 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+)
+
+// SecretHandle runs operations on a single secret in the default project of a Client
+type SecretHandle struct {
+	c    *Client
+	name SecretName
+}
+
+// Secret returns a handle for the secret id in the default project of the client
+func (c *Client) Secret(id string) *SecretHandle {
+	return &SecretHandle{c: c, name: SecretName{Secret: id}}
+}
+
+// ID returns the secret id of the handle
+func (s *SecretHandle) ID() string {
+	return s.name.Secret
+}
+
+// Create creates the secret without any versions
+func (s *SecretHandle) Create(ctx context.Context, opts ...SecretOption) (*pb.Secret, error) {
+	return s.c.CreateSecret(ctx, s.name, opts...)
+}
+
+// CreateWithData creates the secret with payload as its first version
+func (s *SecretHandle) CreateWithData(ctx context.Context, payload []byte, opts ...SecretOption) (*pb.SecretVersion, error) {
+	return s.c.CreateSecretWithData(ctx, s.name.Secret, payload, "", opts...)
+}
+
+// Exists checks if the secret exists
+func (s *SecretHandle) Exists(ctx context.Context) bool {
+	return s.c.SecretExists(ctx, s.name.Secret, "")
+}
+
+// AddVersion adds a new version of the secret
+func (s *SecretHandle) AddVersion(ctx context.Context, payload []byte) (*pb.SecretVersion, error) {
+	return s.c.AddSecretVersion(ctx, s.name, payload)
+}
+
+// Access gets the data of a version, or of the latest version when version is empty
+func (s *SecretHandle) Access(ctx context.Context, version string) (*pb.SecretPayload, error) {
+	return s.c.GetSecret(ctx, s.name.Secret, "", version)
+}
+
+// Delete deletes the secret with all its versions
+func (s *SecretHandle) Delete(ctx context.Context) error {
+	return s.c.DeleteSecret(ctx, s.name)
+}
+
+// DestroyVersion destroys a version of the secret
+func (s *SecretHandle) DestroyVersion(ctx context.Context, version string) (*pb.SecretVersion, error) {
+	return s.c.DestroySecretVersion(ctx, s.name.Version(version))
+}
+
+// VersionMetadata gets the metadata of a version of the secret
+func (s *SecretHandle) VersionMetadata(ctx context.Context, version string) (*pb.SecretVersion, error) {
+	return s.c.GetSecretVersion(ctx, s.name.Version(version))
+}
+
+// DisableVersion disables a version of the secret
+func (s *SecretHandle) DisableVersion(ctx context.Context, version string) (*pb.SecretVersion, error) {
+	return s.c.DisableSecretVersion(ctx, s.name.Version(version))
+}
+
+// EnableVersion enables a version of the secret
+func (s *SecretHandle) EnableVersion(ctx context.Context, version string) (*pb.SecretVersion, error) {
+	return s.c.EnableSecretVersion(ctx, s.name.Version(version))
+}
@@ -0,0 +1,107 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+)
+
+func TestSecretHandle(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+	s := c.Secret("mySecret")
+
+	var names []string
+	GetSecretFunc = func(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+		names = append(names, req.Name)
+		return secretPositiveReturn, nil
+	}
+	CreateSecretFunc = func(ctx context.Context, req *pb.CreateSecretRequest) (*pb.Secret, error) {
+		names = append(names, req.Parent+"/secrets/"+req.SecretId)
+		return &pb.Secret{Name: req.Parent + "/secrets/" + req.SecretId}, nil
+	}
+	AddSecretVersionFunc = func(ctx context.Context, req *pb.AddSecretVersionRequest) (*pb.SecretVersion, error) {
+		names = append(names, req.Parent)
+		return secretVersionPositiveReturn, nil
+	}
+	AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+		names = append(names, req.Name)
+		return &pb.AccessSecretVersionResponse{Payload: &pb.SecretPayload{Data: []byte("mySecret")}}, nil
+	}
+	DeleteSecretFunc = func(ctx context.Context, req *pb.DeleteSecretRequest) error {
+		names = append(names, req.Name)
+		return nil
+	}
+	DestroySecretVersionFunc = func(ctx context.Context, req *pb.DestroySecretVersionRequest) (*pb.SecretVersion, error) {
+		names = append(names, req.Name)
+		return secretVersionPositiveReturn, nil
+	}
+	GetSecretVersionFunc = func(ctx context.Context, req *pb.GetSecretVersionRequest) (*pb.SecretVersion, error) {
+		names = append(names, req.Name)
+		return secretVersionPositiveReturn, nil
+	}
+	DisableSecretVersionFunc = func(ctx context.Context, req *pb.DisableSecretVersionRequest) (*pb.SecretVersion, error) {
+		names = append(names, req.Name)
+		return secretVersionPositiveReturn, nil
+	}
+	EnableSecretVersionFunc = func(ctx context.Context, req *pb.EnableSecretVersionRequest) (*pb.SecretVersion, error) {
+		names = append(names, req.Name)
+		return secretVersionPositiveReturn, nil
+	}
+
+	ctx := context.Background()
+	_, _ = s.Create(ctx)
+	_ = s.Exists(ctx)
+	_, _ = s.AddVersion(ctx, []byte("a new test"))
+	_, _ = s.Access(ctx, "")
+	_, _ = s.DestroyVersion(ctx, "1")
+	_, _ = s.VersionMetadata(ctx, "2")
+	_, _ = s.DisableVersion(ctx, "3")
+	_, _ = s.EnableVersion(ctx, "3")
+	_ = s.Delete(ctx)
+
+	want := []string{
+		"projects/myProject/secrets/mySecret",
+		"projects/myProject/secrets/mySecret",
+		"projects/myProject/secrets/mySecret",
+		"projects/myProject/secrets/mySecret/versions/latest",
+		"projects/myProject/secrets/mySecret/versions/1",
+		"projects/myProject/secrets/mySecret/versions/2",
+		"projects/myProject/secrets/mySecret/versions/3",
+		"projects/myProject/secrets/mySecret/versions/3",
+		"projects/myProject/secrets/mySecret",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("SecretHandle names = %v, want %v", names, want)
+	}
+	if got := s.ID(); got != "mySecret" {
+		t.Errorf("ID() = %v, want %v", got, "mySecret")
+	}
+}
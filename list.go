@@ -0,0 +1,201 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"iter"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/api/iterator"
+)
+
+// ListOption configures ListSecrets and ListVersions
+type ListOption func(*listOptions)
+
+type listOptions struct {
+	filter   string
+	pageSize int32
+}
+
+// ListFilter only lists resources matching filter, see https://cloud.google.com/secret-manager/docs/filtering
+func ListFilter(filter string) ListOption {
+	return func(o *listOptions) {
+		o.filter = filter
+	}
+}
+
+// ListPageSize sets how many resources are fetched per call to Secret Manager
+func ListPageSize(pageSize int32) ListOption {
+	return func(o *listOptions) {
+		o.pageSize = pageSize
+	}
+}
+
+func newListOptions(opts []ListOption) listOptions {
+	var o listOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// SecretIterator iterates over the secrets of a project, fetching pages as needed
+type SecretIterator struct {
+	next func() (*pb.Secret, error)
+}
+
+// Next returns the next secret. It returns iterator.Done when there are no more secrets
+func (it *SecretIterator) Next() (*pb.Secret, error) {
+	return it.next()
+}
+
+// All returns the remaining secrets for use with range, stopping after the first error
+func (it *SecretIterator) All() iter.Seq2[*pb.Secret, error] {
+	return func(yield func(*pb.Secret, error) bool) {
+		for {
+			secret, err := it.Next()
+			if err == iterator.Done || !yield(secret, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// VersionIterator iterates over the versions of a secret, fetching pages as needed
+type VersionIterator struct {
+	next func() (*pb.SecretVersion, error)
+}
+
+// Next returns the next version. It returns iterator.Done when there are no more versions
+func (it *VersionIterator) Next() (*pb.SecretVersion, error) {
+	return it.next()
+}
+
+// All returns the remaining versions for use with range, stopping after the first error
+func (it *VersionIterator) All() iter.Seq2[*pb.SecretVersion, error] {
+	return func(yield func(*pb.SecretVersion, error) bool) {
+		for {
+			version, err := it.Next()
+			if err == iterator.Done || !yield(version, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ListSecrets lists the secrets of project, or of the default project when it is empty
+func (c *Client) ListSecrets(ctx context.Context, project string, opts ...ListOption) *SecretIterator {
+	o := newListOptions(opts)
+	var (
+		buf   []*pb.Secret
+		token string
+		done  bool
+	)
+
+	return &SecretIterator{next: func() (*pb.Secret, error) {
+		for len(buf) == 0 {
+			if done {
+				return nil, iterator.Done
+			}
+
+			name, err := c.projectName(ctx, project)
+			if err != nil {
+				return nil, err
+			}
+
+			listSecretsReq := pb.ListSecretsRequest{
+				Parent:    name.Parent(),
+				PageSize:  o.pageSize,
+				PageToken: token,
+				Filter:    o.filter,
+			}
+
+			var result *pb.ListSecretsResponse
+			err = c.call(ctx, "ListSecrets", name, "", func(ctx context.Context) (err error) {
+				result, err = c.smc.ListSecrets(ctx, &listSecretsReq)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			buf, token = result.Secrets, result.NextPageToken
+			done = token == ""
+		}
+
+		secret := buf[0]
+		buf = buf[1:]
+		return secret, nil
+	}}
+}
+
+// ListVersions lists the versions of a secret, newest first
+func (c *Client) ListVersions(ctx context.Context, name SecretName, opts ...ListOption) *VersionIterator {
+	o := newListOptions(opts)
+	var (
+		buf   []*pb.SecretVersion
+		token string
+		done  bool
+	)
+
+	return &VersionIterator{next: func() (*pb.SecretVersion, error) {
+		for len(buf) == 0 {
+			if done {
+				return nil, iterator.Done
+			}
+
+			name, err := c.secretName(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+
+			listVersionsReq := pb.ListSecretVersionsRequest{
+				Parent:    name.String(),
+				PageSize:  o.pageSize,
+				PageToken: token,
+				Filter:    o.filter,
+			}
+
+			var result *pb.ListSecretVersionsResponse
+			err = c.call(ctx, "ListSecretVersions", name, "", func(ctx context.Context) (err error) {
+				result, err = c.smc.ListSecretVersions(ctx, &listVersionsReq)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			buf, token = result.Versions, result.NextPageToken
+			done = token == ""
+		}
+
+		version := buf[0]
+		buf = buf[1:]
+		return version, nil
+	}}
+}
@@ -0,0 +1,124 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClient_ListSecrets(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+
+	var requests []*pb.ListSecretsRequest
+	ListSecretsFunc = func(ctx context.Context, req *pb.ListSecretsRequest) (*pb.ListSecretsResponse, error) {
+		requests = append(requests, req)
+		switch req.PageToken {
+		case "":
+			return &pb.ListSecretsResponse{Secrets: []*pb.Secret{{Name: "a"}, {Name: "b"}}, NextPageToken: "page2"}, nil
+		case "page2":
+			return &pb.ListSecretsResponse{NextPageToken: "page3"}, nil
+		default:
+			return &pb.ListSecretsResponse{Secrets: []*pb.Secret{{Name: "c"}}}, nil
+		}
+	}
+
+	it := c.ListSecrets(context.Background(), "", ListFilter("labels.env=prod"), ListPageSize(2))
+	var names []string
+	for {
+		secret, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		names = append(names, secret.Name)
+	}
+
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ListSecrets() = %v, want %v", names, want)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("ListSecrets() made %v calls, want 3", len(requests))
+	}
+	for _, req := range requests {
+		if req.Parent != "projects/myProject" || req.Filter != "labels.env=prod" || req.PageSize != 2 {
+			t.Errorf("ListSecrets() request = %v", req)
+		}
+	}
+	if _, err := it.Next(); err != iterator.Done {
+		t.Errorf("Next() after Done error = %v, want iterator.Done", err)
+	}
+}
+
+func TestClient_ListVersions(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+
+	ListSecretVersionsFunc = func(ctx context.Context, req *pb.ListSecretVersionsRequest) (*pb.ListSecretVersionsResponse, error) {
+		if req.Parent != "projects/otherProject/secrets/mySecret" {
+			t.Errorf("ListSecretVersions() parent = %v", req.Parent)
+		}
+		if req.PageToken == "" {
+			return &pb.ListSecretVersionsResponse{Versions: []*pb.SecretVersion{{Name: "2"}}, NextPageToken: "next"}, nil
+		}
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	}
+
+	it := c.ListVersions(context.Background(), SecretName{Project: "otherProject", Secret: "mySecret"})
+	if version, err := it.Next(); err != nil || version.Name != "2" {
+		t.Fatalf("Next() = %v, %v, want version 2", version, err)
+	}
+	if _, err := it.Next(); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Next() error = %v, want %v", err, ErrPermissionDenied)
+	}
+
+	var versions []string
+	var rangeErr error
+	for version, err := range c.ListVersions(context.Background(), SecretName{Project: "otherProject", Secret: "mySecret"}).All() {
+		if err != nil {
+			rangeErr = err
+			continue
+		}
+		versions = append(versions, version.Name)
+	}
+	if !reflect.DeepEqual(versions, []string{"2"}) || !errors.Is(rangeErr, ErrPermissionDenied) {
+		t.Errorf("All() = %v, %v, want [2], %v", versions, rangeErr, ErrPermissionDenied)
+	}
+
+	it = c.ListVersions(context.Background(), SecretName{Secret: "my/secret"})
+	if _, err := it.Next(); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("Next() error = %v, want %v", err, ErrInvalidName)
+	}
+}
@@ -0,0 +1,66 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// WithLogger makes the Client log every Secret Manager call to h. Successful calls are logged at debug level
+// and failures at warn level, with the operation, secret, version and latency. Payloads are never logged.
+// Without it the Client does not log
+func WithLogger(h slog.Handler) Option {
+	return func(c *Client) {
+		c.logger = slog.New(h)
+	}
+}
+
+// logCall logs the outcome of the SecretClient call op on secret
+func (c *Client) logCall(ctx context.Context, op string, secret SecretName, version string, latency time.Duration, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("op", op),
+		slog.String("project", secret.Project),
+		slog.String("secret", secret.Secret),
+	}
+	if version != "" {
+		attrs = append(attrs, slog.String("version", version))
+	}
+	attrs = append(attrs, slog.Duration("latency", latency))
+
+	if err != nil {
+		attrs = append(attrs, slog.String("code", errorCode(err).String()), slog.String("error", err.Error()))
+		c.logger.LogAttrs(ctx, slog.LevelWarn, "secret manager call failed", attrs...)
+		return
+	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "secret manager call", attrs...)
+}
@@ -0,0 +1,100 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClient_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	c, _ := NewClient(context.Background(),
+		WithSecretClient(client),
+		WithProjectID("myProject"),
+		WithLogger(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	)
+
+	AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+		return &pb.AccessSecretVersionResponse{Name: req.Name, Payload: &pb.SecretPayload{Data: []byte("topSecretPayload")}}, nil
+	}
+	DeleteSecretFunc = func(ctx context.Context, req *pb.DeleteSecretRequest) error {
+		return status.Error(codes.NotFound, "Secret not found")
+	}
+
+	_, _ = c.GetSecret(context.Background(), "mySecret", "", "3")
+	_ = c.DeleteSecretAndVersions(context.Background(), "mySecret", "")
+
+	if strings.Contains(buf.String(), "topSecretPayload") {
+		t.Fatalf("log contains the payload: %v", buf.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %v log lines, want 2: %v", len(lines), buf.String())
+	}
+
+	var access, del map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &access); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &del); err != nil {
+		t.Fatal(err)
+	}
+
+	wantAccess := map[string]interface{}{"level": "DEBUG", "op": "AccessSecretVersion", "project": "myProject", "secret": "mySecret", "version": "3"}
+	for k, v := range wantAccess {
+		if access[k] != v {
+			t.Errorf("access log %v = %v, want %v", k, access[k], v)
+		}
+	}
+	if _, ok := access["latency"]; !ok {
+		t.Errorf("access log has no latency: %v", lines[0])
+	}
+
+	wantDelete := map[string]interface{}{"level": "WARN", "op": "DeleteSecret", "secret": "mySecret", "code": "NotFound"}
+	for k, v := range wantDelete {
+		if del[k] != v {
+			t.Errorf("delete log %v = %v, want %v", k, del[k], v)
+		}
+	}
+}
+
+func TestClient_SilentByDefault(t *testing.T) {
+	c, _ := NewClient(context.Background(), WithSecretClient(client), WithProjectID("myProject"))
+	if c.logger != nil {
+		t.Errorf("NewClient() logger = %v, want nil", c.logger)
+	}
+}
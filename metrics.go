@@ -0,0 +1,251 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// opAttribute is the metric attribute naming the SecretClient method called
+const opAttribute = attribute.Key("gsm.op")
+
+// metricsRecorder receives the measurements of a Client
+type metricsRecorder interface {
+	// request records one attempt of the SecretClient call op
+	request(ctx context.Context, op string, latency time.Duration, err error)
+	// retry records that the call op is retried
+	retry(ctx context.Context, op string)
+	// cacheLookup records whether a version was served from the cache
+	cacheLookup(ctx context.Context, hit bool)
+}
+
+// WithMeterProvider sets the provider of the meter that records requests, errors by gRPC code, latency,
+// retries and cache hits and misses. Without it the global provider is used, see otel.SetMeterProvider
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *Client) {
+		c.meterProvider = mp
+	}
+}
+
+// WithPrometheusRegisterer registers the metrics of the client with reg, such as prometheus.DefaultRegisterer.
+// Clients registering with the same reg share their metrics
+func WithPrometheusRegisterer(reg prometheus.Registerer) Option {
+	return func(c *Client) {
+		c.registerer = reg
+	}
+}
+
+// initMetrics creates the recorders of the metric options
+func (c *Client) initMetrics() error {
+	mp := c.meterProvider
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	m, err := newOtelMetrics(mp)
+	if err != nil {
+		return err
+	}
+	c.metrics = append(c.metrics, m)
+
+	if c.registerer != nil {
+		m, err := newPromMetrics(c.registerer)
+		if err != nil {
+			return err
+		}
+		c.metrics = append(c.metrics, m)
+	}
+	return nil
+}
+
+// recordRequest records one attempt of the SecretClient call op
+func (c *Client) recordRequest(ctx context.Context, op string, latency time.Duration, err error) {
+	for _, m := range c.metrics {
+		m.request(ctx, op, latency, err)
+	}
+}
+
+// recordRetry records that the call op is retried
+func (c *Client) recordRetry(ctx context.Context, op string) {
+	for _, m := range c.metrics {
+		m.retry(ctx, op)
+	}
+}
+
+// recordCacheLookup records whether a version was served from the cache
+func (c *Client) recordCacheLookup(ctx context.Context, hit bool) {
+	for _, m := range c.metrics {
+		m.cacheLookup(ctx, hit)
+	}
+}
+
+// otelMetrics records to OpenTelemetry instruments
+type otelMetrics struct {
+	requests    metric.Int64Counter
+	errors      metric.Int64Counter
+	latency     metric.Float64Histogram
+	retries     metric.Int64Counter
+	cacheHits   metric.Int64Counter
+	cacheMisses metric.Int64Counter
+}
+
+// newOtelMetrics creates the instruments of a meter of mp
+func newOtelMetrics(mp metric.MeterProvider) (*otelMetrics, error) {
+	meter := mp.Meter(instrumentationName)
+	m := &otelMetrics{}
+	var err, e error
+
+	m.requests, e = meter.Int64Counter("gsm.requests", metric.WithDescription("Calls made to Secret Manager"))
+	err = errors.Join(err, e)
+	m.errors, e = meter.Int64Counter("gsm.errors", metric.WithDescription("Calls to Secret Manager that failed"))
+	err = errors.Join(err, e)
+	m.latency, e = meter.Float64Histogram("gsm.request.duration", metric.WithDescription("Latency of calls to Secret Manager"), metric.WithUnit("s"))
+	err = errors.Join(err, e)
+	m.retries, e = meter.Int64Counter("gsm.retries", metric.WithDescription("Calls to Secret Manager that were retried"))
+	err = errors.Join(err, e)
+	m.cacheHits, e = meter.Int64Counter("gsm.cache.hits", metric.WithDescription("Versions served from the cache"))
+	err = errors.Join(err, e)
+	m.cacheMisses, e = meter.Int64Counter("gsm.cache.misses", metric.WithDescription("Versions not found in the cache"))
+	err = errors.Join(err, e)
+
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *otelMetrics) request(ctx context.Context, op string, latency time.Duration, err error) {
+	opSet := metric.WithAttributes(opAttribute.String(op))
+	m.requests.Add(ctx, 1, opSet)
+	m.latency.Record(ctx, latency.Seconds(), opSet)
+	if err != nil {
+		m.errors.Add(ctx, 1, metric.WithAttributes(opAttribute.String(op), statusCodeAttribute.Int(int(errorCode(err)))))
+	}
+}
+
+func (m *otelMetrics) retry(ctx context.Context, op string) {
+	m.retries.Add(ctx, 1, metric.WithAttributes(opAttribute.String(op)))
+}
+
+func (m *otelMetrics) cacheLookup(ctx context.Context, hit bool) {
+	if hit {
+		m.cacheHits.Add(ctx, 1)
+		return
+	}
+	m.cacheMisses.Add(ctx, 1)
+}
+
+// promMetrics records to Prometheus collectors
+type promMetrics struct {
+	requests    *prometheus.CounterVec
+	errors      *prometheus.CounterVec
+	latency     *prometheus.HistogramVec
+	retries     *prometheus.CounterVec
+	cacheHits   prometheus.Counter
+	cacheMisses prometheus.Counter
+}
+
+// newPromMetrics registers the collectors with reg, reusing those already registered by another Client
+func newPromMetrics(reg prometheus.Registerer) (*promMetrics, error) {
+	m := &promMetrics{}
+	var err, e error
+
+	m.requests, e = register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gsm_requests_total",
+		Help: "Calls made to Secret Manager.",
+	}, []string{"op"}))
+	err = errors.Join(err, e)
+	m.errors, e = register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gsm_errors_total",
+		Help: "Calls to Secret Manager that failed, by gRPC code.",
+	}, []string{"op", "code"}))
+	err = errors.Join(err, e)
+	m.latency, e = register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gsm_request_duration_seconds",
+		Help:    "Latency of calls to Secret Manager.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"op"}))
+	err = errors.Join(err, e)
+	m.retries, e = register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gsm_retries_total",
+		Help: "Calls to Secret Manager that were retried.",
+	}, []string{"op"}))
+	err = errors.Join(err, e)
+	m.cacheHits, e = register(reg, prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gsm_cache_hits_total",
+		Help: "Versions served from the cache.",
+	}))
+	err = errors.Join(err, e)
+	m.cacheMisses, e = register(reg, prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gsm_cache_misses_total",
+		Help: "Versions not found in the cache.",
+	}))
+	err = errors.Join(err, e)
+
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// register registers collector with reg. When an equal collector is already registered, that one is returned
+func register[T prometheus.Collector](reg prometheus.Registerer, collector T) (T, error) {
+	err := reg.Register(collector)
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(T); ok {
+			return existing, nil
+		}
+	}
+	return collector, err
+}
+
+func (m *promMetrics) request(ctx context.Context, op string, latency time.Duration, err error) {
+	m.requests.WithLabelValues(op).Inc()
+	m.latency.WithLabelValues(op).Observe(latency.Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(op, errorCode(err).String()).Inc()
+	}
+}
+
+func (m *promMetrics) retry(ctx context.Context, op string) {
+	m.retries.WithLabelValues(op).Inc()
+}
+
+func (m *promMetrics) cacheLookup(ctx context.Context, hit bool) {
+	if hit {
+		m.cacheHits.Inc()
+		return
+	}
+	m.cacheMisses.Inc()
+}
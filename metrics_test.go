@@ -0,0 +1,170 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestClient_Metrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	reg := prometheus.NewRegistry()
+
+	c, err := NewClient(context.Background(),
+		WithSecretClient(client),
+		WithProjectID("myProject"),
+		WithoutChecksums(),
+		WithCache(),
+		WithRetry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Nanosecond, MaxBackoff: time.Nanosecond}),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithPrometheusRegisterer(reg),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	calls := 0
+	AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+		calls++
+		if calls == 1 {
+			return nil, status.Error(codes.Unavailable, "unavailable")
+		}
+		return &pb.AccessSecretVersionResponse{Name: req.Name, Payload: &pb.SecretPayload{Data: []byte("data")}}, nil
+	}
+
+	// A miss that is retried once, then a hit
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetSecret(context.Background(), "mySecret", "", "1"); err != nil {
+			t.Fatalf("GetSecret() error = %v", err)
+		}
+	}
+
+	t.Run("OpenTelemetry", func(t *testing.T) {
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(context.Background(), &rm); err != nil {
+			t.Fatalf("Collect() error = %v", err)
+		}
+
+		got := make(map[string]int64)
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				switch data := m.Data.(type) {
+				case metricdata.Sum[int64]:
+					for _, dp := range data.DataPoints {
+						got[m.Name] += dp.Value
+					}
+				case metricdata.Histogram[float64]:
+					for _, dp := range data.DataPoints {
+						got[m.Name] += int64(dp.Count)
+					}
+				}
+			}
+		}
+
+		for name, want := range map[string]int64{
+			"gsm.requests":         2,
+			"gsm.errors":           1,
+			"gsm.request.duration": 2,
+			"gsm.retries":          1,
+			"gsm.cache.hits":       1,
+			"gsm.cache.misses":     1,
+		} {
+			if got[name] != want {
+				t.Errorf("%v = %v, want %v", name, got[name], want)
+			}
+		}
+	})
+
+	t.Run("Prometheus", func(t *testing.T) {
+		m := c.metrics[1].(*promMetrics)
+		for name, tc := range map[string]struct {
+			collector prometheus.Collector
+			want      float64
+		}{
+			"requests":    {m.requests.WithLabelValues("AccessSecretVersion"), 2},
+			"errors":      {m.errors.WithLabelValues("AccessSecretVersion", "Unavailable"), 1},
+			"retries":     {m.retries.WithLabelValues("AccessSecretVersion"), 1},
+			"cacheHits":   {m.cacheHits, 1},
+			"cacheMisses": {m.cacheMisses, 1},
+		} {
+			if got := testutil.ToFloat64(tc.collector); got != tc.want {
+				t.Errorf("%v = %v, want %v", name, got, tc.want)
+			}
+		}
+		if got := testutil.CollectAndCount(m.latency); got != 1 {
+			t.Errorf("latency series = %v, want 1", got)
+		}
+	})
+
+	t.Run("SharedRegisterer", func(t *testing.T) {
+		other, err := NewClient(context.Background(), WithSecretClient(client), WithPrometheusRegisterer(reg))
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		if other.metrics[1].(*promMetrics).requests != c.metrics[1].(*promMetrics).requests {
+			t.Errorf("NewClient() registered new collectors instead of sharing them")
+		}
+	})
+}
+
+func TestClient_MetricsErrorCodes(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c, err := NewClient(context.Background(), WithSecretClient(client), WithProjectID("myProject"), WithPrometheusRegisterer(reg))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	m := c.metrics[1].(*promMetrics)
+
+	AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+		return &pb.AccessSecretVersionResponse{Name: req.Name, Payload: &pb.SecretPayload{Data: []byte("data"), DataCrc32C: proto.Int64(1)}}, nil
+	}
+	_, _ = c.GetSecret(context.Background(), "mySecret", "", "1")
+
+	GetSecretFunc = func(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+		return nil, context.Canceled
+	}
+	_, _ = c.LookupSecret(context.Background(), SecretName{Secret: "mySecret"})
+
+	// the codes match those of the returned *SecretError and of the spans
+	if got := testutil.ToFloat64(m.errors.WithLabelValues("AccessSecretVersion", "DataLoss")); got != 1 {
+		t.Errorf("DataLoss errors = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.errors.WithLabelValues("GetSecret", "Canceled")); got != 1 {
+		t.Errorf("Canceled errors = %v, want 1", got)
+	}
+}
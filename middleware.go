@@ -0,0 +1,192 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"fmt"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Middleware wraps a SecretClient to add behavior around its calls, such as logging or authorization checks
+type Middleware func(next SecretClient) SecretClient
+
+// Chain returns a Middleware that applies mws in order, the first being the outermost
+func Chain(mws ...Middleware) Middleware {
+	return func(next SecretClient) SecretClient {
+		for i := len(mws) - 1; i >= 0; i-- {
+			next = mws[i](next)
+		}
+		return next
+	}
+}
+
+// WithMiddleware wraps the SecretClient of the Client in mws, the first being the outermost.
+// They run outside the circuit breaker and see every attempt of a retried call
+func WithMiddleware(mws ...Middleware) Option {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, mws...)
+	}
+}
+
+// Invoker makes a SecretClient call with req and returns its response
+type Invoker func(ctx context.Context, req proto.Message) (proto.Message, error)
+
+// Interceptor runs around a SecretClient call. method is the SecretClient method called, such as
+// AccessSecretVersion, and invoke makes the call. DeleteSecret responds with an *emptypb.Empty
+type Interceptor func(ctx context.Context, method string, req proto.Message, invoke Invoker) (proto.Message, error)
+
+// Intercept returns a Middleware that runs i around every call of a SecretClient
+func Intercept(i Interceptor) Middleware {
+	return func(next SecretClient) SecretClient {
+		return &interceptedClient{next: next, i: i}
+	}
+}
+
+// WrapRPC returns a Middleware that runs wrap around the calls of a SecretClient that take a Req, leaving the
+// other methods alone. Resp must be the response type of that method, for example
+//
+//	gsm.WrapRPC(func(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest,
+//		next func(context.Context, *secretmanagerpb.AccessSecretVersionRequest) (*secretmanagerpb.AccessSecretVersionResponse, error),
+//	) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+//		...
+//		return next(ctx, req)
+//	})
+func WrapRPC[Req proto.Message, Resp proto.Message](wrap func(ctx context.Context, req Req, next func(ctx context.Context, req Req) (Resp, error)) (Resp, error)) Middleware {
+	return Intercept(func(ctx context.Context, method string, req proto.Message, invoke Invoker) (proto.Message, error) {
+		r, ok := req.(Req)
+		if !ok {
+			return invoke(ctx, req)
+		}
+		return wrap(ctx, r, func(ctx context.Context, req Req) (Resp, error) {
+			resp, err := invoke(ctx, req)
+			return response[Resp](method, resp, err)
+		})
+	})
+}
+
+// interceptedClient is a SecretClient that runs an Interceptor around the calls of another
+type interceptedClient struct {
+	next SecretClient
+	i    Interceptor
+}
+
+// intercept runs the Interceptor of c around call
+func intercept[Req proto.Message, Resp proto.Message](ctx context.Context, c *interceptedClient, method string, req Req, call func(ctx context.Context, req Req) (Resp, error)) (Resp, error) {
+	resp, err := c.i(ctx, method, req, func(ctx context.Context, req proto.Message) (proto.Message, error) {
+		r, ok := req.(Req)
+		if !ok {
+			return nil, fmt.Errorf("gsm: %v called with a %T instead of a %T", method, req, r)
+		}
+		return call(ctx, r)
+	})
+	return response[Resp](method, resp, err)
+}
+
+// response returns the response of method as a Resp. A response of another type, or no response without
+// an error, is reported as an error rather than passed on as a nil response
+func response[Resp proto.Message](method string, resp proto.Message, err error) (Resp, error) {
+	result, ok := resp.(Resp)
+	if err == nil && (!ok || !result.ProtoReflect().IsValid()) {
+		return result, fmt.Errorf("gsm: %v responded with a %T instead of a %T", method, resp, result)
+	}
+	return result, err
+}
+
+// AccessSecretVersion runs the Interceptor around AccessSecretVersion
+func (c *interceptedClient) AccessSecretVersion(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+	return intercept(ctx, c, "AccessSecretVersion", req, c.next.AccessSecretVersion)
+}
+
+// DestroySecretVersion runs the Interceptor around DestroySecretVersion
+func (c *interceptedClient) DestroySecretVersion(ctx context.Context, req *pb.DestroySecretVersionRequest) (*pb.SecretVersion, error) {
+	return intercept(ctx, c, "DestroySecretVersion", req, c.next.DestroySecretVersion)
+}
+
+// CreateSecret runs the Interceptor around CreateSecret
+func (c *interceptedClient) CreateSecret(ctx context.Context, req *pb.CreateSecretRequest) (*pb.Secret, error) {
+	return intercept(ctx, c, "CreateSecret", req, c.next.CreateSecret)
+}
+
+// AddSecretVersion runs the Interceptor around AddSecretVersion
+func (c *interceptedClient) AddSecretVersion(ctx context.Context, req *pb.AddSecretVersionRequest) (*pb.SecretVersion, error) {
+	return intercept(ctx, c, "AddSecretVersion", req, c.next.AddSecretVersion)
+}
+
+// DeleteSecret runs the Interceptor around DeleteSecret
+func (c *interceptedClient) DeleteSecret(ctx context.Context, req *pb.DeleteSecretRequest) error {
+	_, err := intercept(ctx, c, "DeleteSecret", req, func(ctx context.Context, req *pb.DeleteSecretRequest) (*emptypb.Empty, error) {
+		if err := c.next.DeleteSecret(ctx, req); err != nil {
+			return nil, err
+		}
+		return &emptypb.Empty{}, nil
+	})
+	return err
+}
+
+// GetSecret runs the Interceptor around GetSecret
+func (c *interceptedClient) GetSecret(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+	return intercept(ctx, c, "GetSecret", req, c.next.GetSecret)
+}
+
+// UpdateSecret runs the Interceptor around UpdateSecret
+func (c *interceptedClient) UpdateSecret(ctx context.Context, req *pb.UpdateSecretRequest) (*pb.Secret, error) {
+	return intercept(ctx, c, "UpdateSecret", req, c.next.UpdateSecret)
+}
+
+// GetSecretVersion runs the Interceptor around GetSecretVersion
+func (c *interceptedClient) GetSecretVersion(ctx context.Context, req *pb.GetSecretVersionRequest) (*pb.SecretVersion, error) {
+	return intercept(ctx, c, "GetSecretVersion", req, c.next.GetSecretVersion)
+}
+
+// DisableSecretVersion runs the Interceptor around DisableSecretVersion
+func (c *interceptedClient) DisableSecretVersion(ctx context.Context, req *pb.DisableSecretVersionRequest) (*pb.SecretVersion, error) {
+	return intercept(ctx, c, "DisableSecretVersion", req, c.next.DisableSecretVersion)
+}
+
+// EnableSecretVersion runs the Interceptor around EnableSecretVersion
+func (c *interceptedClient) EnableSecretVersion(ctx context.Context, req *pb.EnableSecretVersionRequest) (*pb.SecretVersion, error) {
+	return intercept(ctx, c, "EnableSecretVersion", req, c.next.EnableSecretVersion)
+}
+
+// ListSecrets runs the Interceptor around ListSecrets
+func (c *interceptedClient) ListSecrets(ctx context.Context, req *pb.ListSecretsRequest) (*pb.ListSecretsResponse, error) {
+	return intercept(ctx, c, "ListSecrets", req, c.next.ListSecrets)
+}
+
+// ListSecretVersions runs the Interceptor around ListSecretVersions
+func (c *interceptedClient) ListSecretVersions(ctx context.Context, req *pb.ListSecretVersionsRequest) (*pb.ListSecretVersionsResponse, error) {
+	return intercept(ctx, c, "ListSecretVersions", req, c.next.ListSecretVersions)
+}
+
+// Close closes the wrapped SecretClient
+func (c *interceptedClient) Close() error {
+	return c.next.Close()
+}
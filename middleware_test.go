@@ -0,0 +1,160 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestChain(t *testing.T) {
+	var order []string
+	record := func(name string) Middleware {
+		return Intercept(func(ctx context.Context, method string, req proto.Message, invoke Invoker) (proto.Message, error) {
+			order = append(order, name+":"+method)
+			return invoke(ctx, req)
+		})
+	}
+	DeleteSecretFunc = func(ctx context.Context, req *pb.DeleteSecretRequest) error {
+		order = append(order, "client")
+		return nil
+	}
+
+	smc := Chain(record("outer"), record("inner"))(client)
+	if err := smc.DeleteSecret(context.Background(), &pb.DeleteSecretRequest{Name: "projects/p/secrets/s"}); err != nil {
+		t.Fatalf("DeleteSecret() error = %v", err)
+	}
+
+	want := []string{"outer:DeleteSecret", "inner:DeleteSecret", "client"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("Chain() ran %v, want %v", order, want)
+	}
+
+	if Chain()(client) != SecretClient(client) {
+		t.Errorf("Chain() with no middleware wrapped the SecretClient")
+	}
+}
+
+func TestWithMiddleware(t *testing.T) {
+	t.Run("Intercept", func(t *testing.T) {
+		var methods []string
+		c, err := NewClient(context.Background(), WithSecretClient(client), WithProjectID("myProject"), WithMiddleware(
+			Intercept(func(ctx context.Context, method string, req proto.Message, invoke Invoker) (proto.Message, error) {
+				methods = append(methods, method)
+				return invoke(ctx, req)
+			}),
+		))
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		GetSecretFunc = func(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+			return secretPositiveReturn, nil
+		}
+		DisableSecretVersionFunc = func(ctx context.Context, req *pb.DisableSecretVersionRequest) (*pb.SecretVersion, error) {
+			return secretVersionPositiveReturn, nil
+		}
+
+		secret, err := c.LookupSecret(context.Background(), SecretName{Secret: "mySecret"})
+		if err != nil || !proto.Equal(secret, secretPositiveReturn) {
+			t.Errorf("LookupSecret() = %v, %v, want %v", secret, err, secretPositiveReturn)
+		}
+		if _, err := c.DisableSecretVersion(context.Background(), SecretName{Secret: "mySecret"}.Version("1")); err != nil {
+			t.Errorf("DisableSecretVersion() error = %v", err)
+		}
+
+		want := []string{"GetSecret", "DisableSecretVersion"}
+		if !reflect.DeepEqual(methods, want) {
+			t.Errorf("Interceptor saw %v, want %v", methods, want)
+		}
+	})
+
+	t.Run("WrapRPC", func(t *testing.T) {
+		denied := status.Error(codes.PermissionDenied, "not allowed")
+		c, err := NewClient(context.Background(), WithSecretClient(client), WithProjectID("myProject"), WithMiddleware(
+			WrapRPC(func(ctx context.Context, req *pb.AccessSecretVersionRequest,
+				next func(context.Context, *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error),
+			) (*pb.AccessSecretVersionResponse, error) {
+				if req.Name == "projects/myProject/secrets/forbidden/versions/latest" {
+					return nil, denied
+				}
+				return next(ctx, req)
+			}),
+		))
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		accessed := 0
+		AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+			accessed++
+			return &pb.AccessSecretVersionResponse{Name: req.Name, Payload: &pb.SecretPayload{Data: []byte("data")}}, nil
+		}
+		DeleteSecretFunc = func(ctx context.Context, req *pb.DeleteSecretRequest) error {
+			return nil
+		}
+
+		if _, err := c.AccessSecretVersion(context.Background(), SecretName{Secret: "forbidden"}.Version("latest")); !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("AccessSecretVersion() error = %v, want %v", err, ErrPermissionDenied)
+		}
+		result, err := c.AccessSecretVersion(context.Background(), SecretName{Secret: "allowed"}.Version("latest"))
+		if err != nil || string(result.GetPayload().GetData()) != "data" {
+			t.Errorf("AccessSecretVersion() = %v, %v, want the payload", result, err)
+		}
+		if accessed != 1 {
+			t.Errorf("made %v AccessSecretVersion calls, want 1", accessed)
+		}
+		if err := c.DeleteSecret(context.Background(), SecretName{Secret: "forbidden"}); err != nil {
+			t.Errorf("DeleteSecret() error = %v, want the call to pass through", err)
+		}
+	})
+
+	t.Run("WrongResponse", func(t *testing.T) {
+		responses := []proto.Message{&pb.Secret{}, nil, (*pb.AccessSecretVersionResponse)(nil)}
+		c, err := NewClient(context.Background(), WithSecretClient(client), WithProjectID("myProject"), WithMiddleware(
+			Intercept(func(ctx context.Context, method string, req proto.Message, invoke Invoker) (proto.Message, error) {
+				resp := responses[0]
+				responses = responses[1:]
+				return resp, nil
+			}),
+		))
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+
+		for range 3 {
+			if payload, err := c.GetSecret(context.Background(), "mySecret", "", "1"); err == nil {
+				t.Errorf("GetSecret() = %v, want an error", payload)
+			}
+		}
+	})
+}
@@ -28,13 +28,14 @@ package gsm
 
 import (
 	"context"
-	
-	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+
+	secretmanagerpb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
 )
 
 // Declare Mock funcs
 var (
 	GetSecretFunc            func(ctx context.Context, req *secretmanagerpb.GetSecretRequest) (*secretmanagerpb.Secret, error)
+	UpdateSecretFunc         func(ctx context.Context, req *secretmanagerpb.UpdateSecretRequest) (*secretmanagerpb.Secret, error)
 	AccessSecretVersionFunc  func(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest) (*secretmanagerpb.AccessSecretVersionResponse, error)
 	DestroySecretVersionFunc func(ctx context.Context, req *secretmanagerpb.DestroySecretVersionRequest) (*secretmanagerpb.SecretVersion, error)
 	CreateSecretFunc         func(ctx context.Context, req *secretmanagerpb.CreateSecretRequest) (*secretmanagerpb.Secret, error)
@@ -43,11 +44,14 @@ var (
 	GetSecretVersionFunc     func(ctx context.Context, req *secretmanagerpb.GetSecretVersionRequest) (*secretmanagerpb.SecretVersion, error)
 	DisableSecretVersionFunc func(ctx context.Context, req *secretmanagerpb.DisableSecretVersionRequest) (*secretmanagerpb.SecretVersion, error)
 	EnableSecretVersionFunc  func(ctx context.Context, req *secretmanagerpb.EnableSecretVersionRequest) (*secretmanagerpb.SecretVersion, error)
+	ListSecretsFunc          func(ctx context.Context, req *secretmanagerpb.ListSecretsRequest) (*secretmanagerpb.ListSecretsResponse, error)
+	ListSecretVersionsFunc   func(ctx context.Context, req *secretmanagerpb.ListSecretVersionsRequest) (*secretmanagerpb.ListSecretVersionsResponse, error)
 )
 
 // MockClient is the mock client
 type MockClient struct {
 	GetSecretFunc            func(ctx context.Context, req *secretmanagerpb.GetSecretRequest) (*secretmanagerpb.Secret, error)
+	UpdateSecretFunc         func(ctx context.Context, req *secretmanagerpb.UpdateSecretRequest) (*secretmanagerpb.Secret, error)
 	AccessSecretVersionFunc  func(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest) (*secretmanagerpb.AccessSecretVersionResponse, error)
 	DestroySecretVersionFunc func(ctx context.Context, req *secretmanagerpb.DestroySecretVersionRequest) (*secretmanagerpb.SecretVersion, error)
 	CreateSecretFunc         func(ctx context.Context, req *secretmanagerpb.CreateSecretRequest) (*secretmanagerpb.Secret, error)
@@ -56,6 +60,8 @@ type MockClient struct {
 	GetSecretVersionFunc     func(ctx context.Context, req *secretmanagerpb.GetSecretVersionRequest) (*secretmanagerpb.SecretVersion, error)
 	DisableSecretVersionFunc func(ctx context.Context, req *secretmanagerpb.DisableSecretVersionRequest) (*secretmanagerpb.SecretVersion, error)
 	EnableSecretVersionFunc  func(ctx context.Context, req *secretmanagerpb.EnableSecretVersionRequest) (*secretmanagerpb.SecretVersion, error)
+	ListSecretsFunc          func(ctx context.Context, req *secretmanagerpb.ListSecretsRequest) (*secretmanagerpb.ListSecretsResponse, error)
+	ListSecretVersionsFunc   func(ctx context.Context, req *secretmanagerpb.ListSecretVersionsRequest) (*secretmanagerpb.ListSecretVersionsResponse, error)
 }
 
 // GetSecret Mock Get Secret
@@ -63,6 +69,11 @@ func (m *MockClient) GetSecret(ctx context.Context, req *secretmanagerpb.GetSecr
 	return GetSecretFunc(ctx, req)
 }
 
+// UpdateSecret Mock Update Secret
+func (m *MockClient) UpdateSecret(ctx context.Context, req *secretmanagerpb.UpdateSecretRequest) (*secretmanagerpb.Secret, error) {
+	return UpdateSecretFunc(ctx, req)
+}
+
 // AccessSecretVersion Mock Access SecretVersion
 func (m *MockClient) AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest) (*secretmanagerpb.AccessSecretVersionResponse, error) {
 	return AccessSecretVersionFunc(ctx, req)
@@ -103,6 +114,16 @@ func (m *MockClient) EnableSecretVersion(ctx context.Context, req *secretmanager
 	return EnableSecretVersionFunc(ctx, req)
 }
 
+// ListSecrets Mock List Secrets
+func (m *MockClient) ListSecrets(ctx context.Context, req *secretmanagerpb.ListSecretsRequest) (*secretmanagerpb.ListSecretsResponse, error) {
+	return ListSecretsFunc(ctx, req)
+}
+
+// ListSecretVersions Mock List Secret Versions
+func (m *MockClient) ListSecretVersions(ctx context.Context, req *secretmanagerpb.ListSecretVersionsRequest) (*secretmanagerpb.ListSecretVersionsResponse, error) {
+	return ListSecretVersionsFunc(ctx, req)
+}
+
 // Close Mock Close Client
 func (m *MockClient) Close() error {
 	return nil
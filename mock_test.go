@@ -30,8 +30,8 @@ import (
 	"context"
 	"errors"
 	"testing"
-	
-	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+
+	secretmanagerpb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
 )
 
 func TestMockClient_Close(t *testing.T) {
@@ -0,0 +1,228 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LatestVersion is the alias Secret Manager resolves to the newest enabled version of a secret
+const LatestVersion = "latest"
+
+// maxSecretIDLength is the longest secret id Secret Manager accepts
+const maxSecretIDLength = 255
+
+var (
+	secretIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	projectPattern  = regexp.MustCompile(`^[A-Za-z0-9.:_-]+$`)
+	numberPattern   = regexp.MustCompile(`^[0-9]+$`)
+	aliasPattern    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]{0,62}$`)
+)
+
+// SecretName identifies a secret, projects/{project}/secrets/{secret}.
+// An empty Project stands for the default project of the Client
+type SecretName struct {
+	Project string
+	Secret  string
+}
+
+// VersionName identifies a secret version, projects/{project}/secrets/{secret}/versions/{version}.
+// Version is a version number or an alias such as latest; empty stands for latest when reading a version,
+// while calls that change a version reject it
+type VersionName struct {
+	SecretName
+	Version string
+}
+
+// ParseSecretName parses a name in the form projects/{project}/secrets/{secret}
+func ParseSecretName(name string) (SecretName, error) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 4 || parts[0] != "projects" || parts[2] != "secrets" {
+		return SecretName{}, fmt.Errorf("%w: %q is not of the form projects/*/secrets/*", ErrInvalidName, name)
+	}
+
+	n := SecretName{Project: parts[1], Secret: parts[3]}
+	if err := n.Validate(); err != nil {
+		return SecretName{}, err
+	}
+	return n, nil
+}
+
+// ParseVersionName parses a name in the form projects/{project}/secrets/{secret}/versions/{version}
+func ParseVersionName(name string) (VersionName, error) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 6 || parts[0] != "projects" || parts[2] != "secrets" || parts[4] != "versions" {
+		return VersionName{}, fmt.Errorf("%w: %q is not of the form projects/*/secrets/*/versions/*", ErrInvalidName, name)
+	}
+
+	n := VersionName{SecretName: SecretName{Project: parts[1], Secret: parts[3]}, Version: parts[5]}
+	if err := n.Validate(); err != nil {
+		return VersionName{}, err
+	}
+	return n, nil
+}
+
+// String returns the resource name of the secret
+func (n SecretName) String() string {
+	return fmt.Sprintf("projects/%v/secrets/%v", n.Project, n.Secret)
+}
+
+// Parent returns the resource name of the project holding the secret
+func (n SecretName) Parent() string {
+	return fmt.Sprintf("projects/%v", n.Project)
+}
+
+// Version returns the name of a version of the secret
+func (n SecretName) Version(version string) VersionName {
+	return VersionName{SecretName: n, Version: version}
+}
+
+// IsProjectNumber reports whether the project is given by number rather than by id
+func (n SecretName) IsProjectNumber() bool {
+	return numberPattern.MatchString(n.Project)
+}
+
+// Validate checks the project and secret id of the name
+func (n SecretName) Validate() error {
+	if !projectPattern.MatchString(n.Project) {
+		return fmt.Errorf("%w: invalid project %q", ErrInvalidName, n.Project)
+	}
+	if n.Secret == "" {
+		return fmt.Errorf("%w: empty secret id", ErrInvalidName)
+	}
+	if len(n.Secret) > maxSecretIDLength {
+		return fmt.Errorf("%w: secret id is longer than %v characters", ErrInvalidName, maxSecretIDLength)
+	}
+	if !secretIDPattern.MatchString(n.Secret) {
+		return fmt.Errorf("%w: secret id %q may only contain letters, numbers, dashes and underscores", ErrInvalidName, n.Secret)
+	}
+	return nil
+}
+
+// String returns the resource name of the version
+func (n VersionName) String() string {
+	return fmt.Sprintf("%v/versions/%v", n.SecretName, n.version())
+}
+
+// IsLatest reports whether the name refers to the latest version
+func (n VersionName) IsLatest() bool {
+	return n.version() == LatestVersion
+}
+
+// Number returns the version number, if the name refers to a version by number
+func (n VersionName) Number() (int64, bool) {
+	if !numberPattern.MatchString(n.Version) {
+		return 0, false
+	}
+	number, err := strconv.ParseInt(n.Version, 10, 64)
+	return number, err == nil && number > 0
+}
+
+// IsAlias reports whether the name refers to a version by an alias other than latest
+func (n VersionName) IsAlias() bool {
+	return !n.IsLatest() && aliasPattern.MatchString(n.Version)
+}
+
+// Validate checks the secret and version of the name
+func (n VersionName) Validate() error {
+	if err := n.SecretName.Validate(); err != nil {
+		return err
+	}
+	if _, ok := n.Number(); ok || n.IsLatest() || n.IsAlias() {
+		return nil
+	}
+	return fmt.Errorf("%w: invalid version %q", ErrInvalidName, n.Version)
+}
+
+func (n VersionName) version() string {
+	if n.Version == "" {
+		return LatestVersion
+	}
+	return n.Version
+}
+
+// resourceName returns the resource name of secret, of its version when version is set,
+// or of its project when it has no secret id
+func resourceName(secret SecretName, version string) string {
+	if secret.Secret == "" {
+		return secret.Parent()
+	}
+	if version == "" {
+		return secret.String()
+	}
+	return secret.Version(version).String()
+}
+
+// projectName fills in the default project and validates it. The returned name has no secret id
+func (c *Client) projectName(ctx context.Context, project string) (SecretName, error) {
+	if project == "" {
+		defaultProject, err := c.ProjectID(ctx)
+		if err != nil {
+			return SecretName{}, err
+		}
+		project = defaultProject
+	}
+	if !projectPattern.MatchString(project) {
+		return SecretName{}, fmt.Errorf("%w: invalid project %q", ErrInvalidName, project)
+	}
+	return SecretName{Project: project}, nil
+}
+
+// secretName fills in the default project and validates the name
+func (c *Client) secretName(ctx context.Context, n SecretName) (SecretName, error) {
+	if n.Project == "" {
+		project, err := c.ProjectID(ctx)
+		if err != nil {
+			return SecretName{}, err
+		}
+		n.Project = project
+	}
+	return n, n.Validate()
+}
+
+// versionName fills in the default project and validates the name
+func (c *Client) versionName(ctx context.Context, n VersionName) (VersionName, error) {
+	secret, err := c.secretName(ctx, n.SecretName)
+	if err != nil {
+		return VersionName{}, err
+	}
+	n.SecretName = secret
+	return n, n.Validate()
+}
+
+// changedVersionName is versionName for calls that change a version. They must name the version,
+// an empty version is not taken to mean latest
+func (c *Client) changedVersionName(ctx context.Context, n VersionName) (VersionName, error) {
+	if n.Version == "" {
+		return VersionName{}, fmt.Errorf("%w: empty version", ErrInvalidName)
+	}
+	return c.versionName(ctx, n)
+}
@@ -0,0 +1,161 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+)
+
+func TestParseSecretName(t *testing.T) {
+	parseTest := func(name string, want SecretName, wantErr bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			got, err := ParseSecretName(name)
+			if (err != nil) != wantErr {
+				t.Errorf("ParseSecretName() error = %v, wantErr %v", err, wantErr)
+				return
+			}
+			if got != want {
+				t.Errorf("ParseSecretName() got = %v, want %v", got, want)
+			}
+			if !wantErr && got.String() != name {
+				t.Errorf("String() = %v, want %v", got.String(), name)
+			}
+		}
+	}
+
+	t.Run("ProjectID", parseTest("projects/my-project/secrets/my_secret-1", SecretName{Project: "my-project", Secret: "my_secret-1"}, false))
+	t.Run("ProjectNumber", parseTest("projects/123456/secrets/mySecret", SecretName{Project: "123456", Secret: "mySecret"}, false))
+	t.Run("VersionName", parseTest("projects/my-project/secrets/mySecret/versions/1", SecretName{}, true))
+	t.Run("BadSecretID", parseTest("projects/my-project/secrets/my.secret", SecretName{}, true))
+	t.Run("EmptySecretID", parseTest("projects/my-project/secrets/", SecretName{}, true))
+	t.Run("TooLong", parseTest("projects/my-project/secrets/"+strings.Repeat("a", 256), SecretName{}, true))
+	t.Run("MaxLength", parseTest("projects/my-project/secrets/"+strings.Repeat("a", 255), SecretName{Project: "my-project", Secret: strings.Repeat("a", 255)}, false))
+	t.Run("NotAName", parseTest("mySecret", SecretName{}, true))
+
+	if n, _ := ParseSecretName("projects/123456/secrets/mySecret"); !n.IsProjectNumber() {
+		t.Errorf("IsProjectNumber() = false, want true")
+	}
+	if n, _ := ParseSecretName("projects/my-project/secrets/mySecret"); n.IsProjectNumber() {
+		t.Errorf("IsProjectNumber() = true, want false")
+	}
+}
+
+func TestParseVersionName(t *testing.T) {
+	parseTest := func(name string, want VersionName, wantErr bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			got, err := ParseVersionName(name)
+			if (err != nil) != wantErr {
+				t.Errorf("ParseVersionName() error = %v, wantErr %v", err, wantErr)
+				return
+			}
+			if got != want {
+				t.Errorf("ParseVersionName() got = %v, want %v", got, want)
+			}
+			if !wantErr && got.String() != name {
+				t.Errorf("String() = %v, want %v", got.String(), name)
+			}
+		}
+	}
+
+	secret := SecretName{Project: "my-project", Secret: "mySecret"}
+	t.Run("Latest", parseTest("projects/my-project/secrets/mySecret/versions/latest", secret.Version("latest"), false))
+	t.Run("Number", parseTest("projects/my-project/secrets/mySecret/versions/12", secret.Version("12"), false))
+	t.Run("Alias", parseTest("projects/my-project/secrets/mySecret/versions/current", secret.Version("current"), false))
+	t.Run("Zero", parseTest("projects/my-project/secrets/mySecret/versions/0", VersionName{}, true))
+	t.Run("BadAlias", parseTest("projects/my-project/secrets/mySecret/versions/1abc", VersionName{}, true))
+	t.Run("SecretName", parseTest("projects/my-project/secrets/mySecret", VersionName{}, true))
+
+	if n := secret.Version(""); !n.IsLatest() || n.String() != "projects/my-project/secrets/mySecret/versions/latest" {
+		t.Errorf("Version(\"\") = %v, want latest", n)
+	}
+	if number, ok := secret.Version("12").Number(); !ok || number != 12 {
+		t.Errorf("Number() = %v, %v, want 12, true", number, ok)
+	}
+	if secret.Version("latest").IsAlias() || !secret.Version("current").IsAlias() {
+		t.Errorf("IsAlias() mismatch")
+	}
+}
+
+func TestClient_InvalidNames(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+
+	called := false
+	AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+		called = true
+		return &pb.AccessSecretVersionResponse{Name: req.Name, Payload: &pb.SecretPayload{}}, nil
+	}
+
+	if _, err := c.GetSecret(context.Background(), "my/secret", "", ""); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("GetSecret() error = %v, want %v", err, ErrInvalidName)
+	}
+	if _, err := c.AccessSecretVersion(context.Background(), SecretName{Secret: "mySecret"}.Version("-1")); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("AccessSecretVersion() error = %v, want %v", err, ErrInvalidName)
+	}
+	if called {
+		t.Errorf("AccessSecretVersion called with an invalid name")
+	}
+
+	changed := false
+	DestroySecretVersionFunc = func(ctx context.Context, req *pb.DestroySecretVersionRequest) (*pb.SecretVersion, error) {
+		changed = true
+		return &pb.SecretVersion{Name: req.Name}, nil
+	}
+	DisableSecretVersionFunc = func(ctx context.Context, req *pb.DisableSecretVersionRequest) (*pb.SecretVersion, error) {
+		changed = true
+		return &pb.SecretVersion{Name: req.Name}, nil
+	}
+	EnableSecretVersionFunc = func(ctx context.Context, req *pb.EnableSecretVersionRequest) (*pb.SecretVersion, error) {
+		changed = true
+		return &pb.SecretVersion{Name: req.Name}, nil
+	}
+	// an empty version only means latest for reads
+	if _, err := c.DeleteSecretVersion(context.Background(), "mySecret", "", ""); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("DeleteSecretVersion() error = %v, want %v", err, ErrInvalidName)
+	}
+	if _, err := c.DisableSecret(context.Background(), "mySecret", "", ""); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("DisableSecret() error = %v, want %v", err, ErrInvalidName)
+	}
+	if _, err := c.EnableSecret(context.Background(), "mySecret", "", ""); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("EnableSecret() error = %v, want %v", err, ErrInvalidName)
+	}
+	if changed {
+		t.Errorf("a version was changed without naming it")
+	}
+
+	got, err := c.AccessSecretVersion(context.Background(), SecretName{Secret: "mySecret"}.Version("3"))
+	if err != nil {
+		t.Fatalf("AccessSecretVersion() error = %v", err)
+	}
+	if want := "projects/myProject/secrets/mySecret/versions/3"; got.Name != want {
+		t.Errorf("AccessSecretVersion() name = %v, want %v", got.Name, want)
+	}
+}
@@ -0,0 +1,168 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// EventType is the type of change a Secret Manager Pub/Sub notification reports
+type EventType string
+
+// Event types of Secret Manager notifications, see https://cloud.google.com/secret-manager/docs/event-notifications
+const (
+	EventSecretCreate         EventType = "SECRET_CREATE"
+	EventSecretUpdate         EventType = "SECRET_UPDATE"
+	EventSecretDelete         EventType = "SECRET_DELETE"
+	EventSecretRotate         EventType = "SECRET_ROTATE"
+	EventSecretVersionAdd     EventType = "SECRET_VERSION_ADD"
+	EventSecretVersionEnable  EventType = "SECRET_VERSION_ENABLE"
+	EventSecretVersionDisable EventType = "SECRET_VERSION_DISABLE"
+	EventSecretVersionDestroy EventType = "SECRET_VERSION_DESTROY"
+	EventTopicConfigured      EventType = "TOPIC_CONFIGURED"
+)
+
+// Attributes of Secret Manager notifications
+const (
+	eventTypeAttribute = "eventType"
+	secretIDAttribute  = "secretId"
+	versionIDAttribute = "versionId"
+	timestampAttribute = "timestamp"
+)
+
+// Notification is a parsed Secret Manager Pub/Sub notification
+type Notification struct {
+	EventType EventType
+	Secret    SecretName
+	// Version is set for SECRET_VERSION_* events
+	Version VersionName
+	// Time is when the event happened, zero if the notification has no timestamp
+	Time time.Time
+}
+
+// ParseNotification parses the attributes of a Secret Manager Pub/Sub message
+func ParseNotification(attributes map[string]string) (Notification, error) {
+	n := Notification{EventType: EventType(attributes[eventTypeAttribute])}
+	if n.EventType == "" {
+		return Notification{}, fmt.Errorf("%w: notification has no %v attribute", ErrInvalidName, eventTypeAttribute)
+	}
+
+	secret, err := ParseSecretName(attributes[secretIDAttribute])
+	if err != nil {
+		return Notification{}, err
+	}
+	n.Secret = secret
+
+	if versionID := attributes[versionIDAttribute]; versionID != "" {
+		if n.Version, err = ParseVersionName(versionID); err != nil {
+			return Notification{}, err
+		}
+	}
+	if timestamp := attributes[timestampAttribute]; timestamp != "" {
+		if n.Time, err = time.Parse(time.RFC3339Nano, timestamp); err != nil {
+			return Notification{}, fmt.Errorf("gsm: notification timestamp: %w", err)
+		}
+	}
+	return n, nil
+}
+
+// changesLatest reports whether the event may have changed the latest version of the secret
+func (t EventType) changesLatest() bool {
+	switch t {
+	case EventSecretVersionAdd, EventSecretVersionEnable, EventSecretVersionDisable, EventSecretVersionDestroy:
+		return true
+	}
+	return false
+}
+
+// Subscriber delivers Pub/Sub messages until ctx is done. A message whose handler returns an error
+// should be redelivered. It is implemented by wrapping a Pub/Sub subscription, an emulator or a fake
+type Subscriber interface {
+	Receive(ctx context.Context, handler func(ctx context.Context, attributes map[string]string) error) error
+}
+
+// SubscriberFunc adapts a function to a Subscriber
+type SubscriberFunc func(ctx context.Context, handler func(ctx context.Context, attributes map[string]string) error) error
+
+// Receive calls f
+func (f SubscriberFunc) Receive(ctx context.Context, handler func(ctx context.Context, attributes map[string]string) error) error {
+	return f(ctx, handler)
+}
+
+// WatchNotifications is like Watch, but checks the latest version when sub delivers a notification
+// about a new, enabled, disabled or destroyed version of the secret instead of polling.
+// Notifications for other secrets are ignored. A notification is only nacked for redelivery when checking
+// failed for a transient reason such as Secret Manager being unavailable; other failures, such as a disabled
+// latest version, go to the WatchOnError function only. It returns the error of sub.Receive
+func (c *Client) WatchNotifications(ctx context.Context, name SecretName, sub Subscriber, fn func(Change), opts ...WatchOption) error {
+	o := newWatchOptions(opts)
+	name, err := c.secretName(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	w := &watcher{c: c, name: name, fn: fn}
+	if err := w.check(ctx); err != nil && o.onError != nil {
+		o.onError(err)
+	}
+
+	return sub.Receive(ctx, func(ctx context.Context, attributes map[string]string) error {
+		n, err := ParseNotification(attributes)
+		if err != nil {
+			if o.onError != nil {
+				o.onError(err)
+			}
+			// redelivering a malformed message won't help
+			return nil
+		}
+		if !n.EventType.changesLatest() || !sameSecret(n.Secret, name) {
+			return nil
+		}
+
+		if err := w.check(ctx); err != nil {
+			if o.onError != nil {
+				o.onError(err)
+			}
+			// only a transient failure is worth a redelivery, a disabled latest version stays disabled
+			if servesStale(err) || ctx.Err() != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// sameSecret reports whether a secret named in a notification is the watched secret.
+// Notifications may name the project by number, in which case only the secret id is compared
+func sameSecret(notified SecretName, watched SecretName) bool {
+	if notified.Secret != watched.Secret {
+		return false
+	}
+	return notified.Project == watched.Project || notified.IsProjectNumber() != watched.IsProjectNumber()
+}
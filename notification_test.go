@@ -0,0 +1,207 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseNotification(t *testing.T) {
+	parseTest := func(attributes map[string]string, want Notification, wantErr bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			got, err := ParseNotification(attributes)
+			if (err != nil) != wantErr {
+				t.Errorf("ParseNotification() error = %v, wantErr %v", err, wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ParseNotification() = %+v, want %+v", got, want)
+			}
+		}
+	}
+
+	secret := SecretName{Project: "myProject", Secret: "mySecret"}
+	t.Run("VersionAdd", parseTest(map[string]string{
+		"eventType":  "SECRET_VERSION_ADD",
+		"secretId":   "projects/myProject/secrets/mySecret",
+		"versionId":  "projects/myProject/secrets/mySecret/versions/3",
+		"dataFormat": "JSON",
+		"timestamp":  "2030-01-02T03:04:05.678Z",
+	}, Notification{
+		EventType: EventSecretVersionAdd,
+		Secret:    secret,
+		Version:   secret.Version("3"),
+		Time:      time.Date(2030, 1, 2, 3, 4, 5, 678000000, time.UTC),
+	}, false))
+	t.Run("Rotate", parseTest(map[string]string{
+		"eventType": "SECRET_ROTATE",
+		"secretId":  "projects/myProject/secrets/mySecret",
+	}, Notification{EventType: EventSecretRotate, Secret: secret}, false))
+	t.Run("NoEventType", parseTest(map[string]string{"secretId": "projects/myProject/secrets/mySecret"}, Notification{}, true))
+	t.Run("BadSecretID", parseTest(map[string]string{"eventType": "SECRET_ROTATE", "secretId": "mySecret"}, Notification{}, true))
+	t.Run("BadTimestamp", parseTest(map[string]string{
+		"eventType": "SECRET_ROTATE",
+		"secretId":  "projects/myProject/secrets/mySecret",
+		"timestamp": "yesterday",
+	}, Notification{}, true))
+}
+
+// fakeSubscriber delivers the attributes sent on messages to the handler and the handler results on acks
+type fakeSubscriber struct {
+	messages chan map[string]string
+	acks     chan error
+}
+
+func (f *fakeSubscriber) Receive(ctx context.Context, handler func(ctx context.Context, attributes map[string]string) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case attributes := <-f.messages:
+			f.acks <- handler(ctx, attributes)
+		}
+	}
+}
+
+func TestClient_WatchNotifications(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+	latest := &fakeLatest{version: 1}
+	latest.install()
+
+	sub := &fakeSubscriber{messages: make(chan map[string]string), acks: make(chan error)}
+	changes := make(chan Change, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- c.WatchNotifications(ctx, SecretName{Secret: "mySecret"}, sub, func(change Change) {
+			changes <- change
+		})
+	}()
+
+	if got := <-changes; got.NewVersion != 1 || string(got.NewData) != "data-1" {
+		t.Errorf("first change = %+v, want version 1", got)
+	}
+
+	notify := func(eventType string, secretID string) {
+		t.Helper()
+		sub.messages <- map[string]string{"eventType": eventType, "secretId": secretID}
+		if err := <-sub.acks; err != nil {
+			t.Errorf("handler error = %v", err)
+		}
+	}
+
+	latest.set(2, nil)
+	notify("SECRET_UPDATE", "projects/myProject/secrets/mySecret")
+	notify("SECRET_VERSION_ADD", "projects/myProject/secrets/otherSecret")
+	if len(changes) != 0 {
+		t.Errorf("changed on a notification that does not move latest")
+	}
+
+	// notifications may name the project by number
+	notify("SECRET_VERSION_ADD", "projects/123456/secrets/mySecret")
+	if got, want := <-changes, (Change{Secret: SecretName{Project: "myProject", Secret: "mySecret"}, OldVersion: 1, NewVersion: 2, OldData: []byte("data-1"), NewData: []byte("data-2")}); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("change = %+v, want %+v", got, want)
+	}
+
+	notify("SECRET_VERSION_ENABLE", "projects/myProject/secrets/mySecret")
+	if len(changes) != 0 {
+		t.Errorf("changed although latest is still version 2")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("WatchNotifications() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestClient_WatchNotificationsAcks(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+	latest := &fakeLatest{version: 1}
+	latest.install()
+
+	sub := &fakeSubscriber{messages: make(chan map[string]string), acks: make(chan error)}
+	errs := make(chan error, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- c.WatchNotifications(ctx, SecretName{Secret: "mySecret"}, sub, func(change Change) {}, WatchOnError(func(err error) {
+			errs <- err
+		}))
+	}()
+
+	notify := func(eventType string) error {
+		sub.messages <- map[string]string{"eventType": eventType, "secretId": "projects/myProject/secrets/mySecret"}
+		return <-sub.acks
+	}
+
+	// the latest version was disabled: reported, but acknowledged since a redelivery finds it disabled again
+	latest.mu.Lock()
+	latest.disabled = true
+	latest.mu.Unlock()
+	if err := notify("SECRET_VERSION_DISABLE"); err != nil {
+		t.Errorf("handler error = %v, want the message acknowledged", err)
+	}
+	if err := <-errs; err == nil || !strings.Contains(err.Error(), "DISABLED") {
+		t.Errorf("reported error = %v, want the latest version disabled", err)
+	}
+
+	// Secret Manager is unavailable: the message is redelivered
+	latest.set(1, status.Error(codes.Unavailable, "unavailable"))
+	if err := notify("SECRET_VERSION_ENABLE"); status.Code(errors.Unwrap(err)) != codes.Unavailable {
+		t.Errorf("handler error = %v, want %v", err, codes.Unavailable)
+	}
+	<-errs
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("WatchNotifications() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestSubscriberFunc(t *testing.T) {
+	var sub Subscriber = SubscriberFunc(func(ctx context.Context, handler func(ctx context.Context, attributes map[string]string) error) error {
+		return handler(ctx, map[string]string{"eventType": "SECRET_ROTATE"})
+	})
+	want := errors.New("handled")
+	if err := sub.Receive(context.Background(), func(ctx context.Context, attributes map[string]string) error {
+		if attributes["eventType"] != "SECRET_ROTATE" {
+			t.Errorf("attributes = %v", attributes)
+		}
+		return want
+	}); err != want {
+		t.Errorf("Receive() error = %v, want %v", err, want)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"google.golang.org/api/option"
+)
+
+// Option configures a Client created by NewClient
+type Option func(*Client)
+
+// WithSecretClient makes the Client use an existing SecretClient, such as a MockClient,
+// instead of dialing Secret Manager
+func WithSecretClient(smc SecretClient) Option {
+	return func(c *Client) {
+		c.smc = smc
+	}
+}
+
+// WithClientOptions passes options such as an endpoint or credentials to the Secret Manager client.
+// They are ignored when WithSecretClient is used
+func WithClientOptions(opts ...option.ClientOption) Option {
+	return func(c *Client) {
+		c.clientOpts = append(c.clientOpts, opts...)
+	}
+}
+
+// WithProjectID sets the project used when a method is called with an empty projectId.
+// Without it the project is detected, see DetectProjectID
+func WithProjectID(projectID string) Option {
+	return func(c *Client) {
+		c.projectID = projectID
+	}
+}
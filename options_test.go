@@ -0,0 +1,92 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"testing"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestNewClient(t *testing.T) {
+	t.Run("WithSecretClient", func(t *testing.T) {
+		c, err := NewClient(context.Background(), WithSecretClient(client), WithProjectID("myProject"))
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		if c.smc != client {
+			t.Errorf("NewClient() smc = %v, want %v", c.smc, client)
+		}
+		if got, _ := c.ProjectID(context.Background()); got != "myProject" {
+			t.Errorf("ProjectID() = %v, want %v", got, "myProject")
+		}
+		if err := c.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+
+	t.Run("WithClientOptions", func(t *testing.T) {
+		c, err := NewClient(context.Background(), WithClientOptions(
+			option.WithEndpoint("localhost:0"),
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		))
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		if _, ok := c.smc.(*grpcClient); !ok {
+			t.Errorf("NewClient() smc = %T, want *grpcClient", c.smc)
+		}
+		if err := c.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+}
+
+func TestClient_DefaultProject(t *testing.T) {
+	c, _ := NewClient(context.Background(), WithSecretClient(client), WithProjectID("myProject"))
+
+	var got string
+	AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+		got = req.Name
+		return &pb.AccessSecretVersionResponse{Payload: &pb.SecretPayload{}}, nil
+	}
+
+	_, _ = c.GetSecret(context.Background(), "mySecret", "", "1")
+	if want := "projects/myProject/secrets/mySecret/versions/1"; got != want {
+		t.Errorf("GetSecret() name = %v, want %v", got, want)
+	}
+
+	_, _ = c.GetSecret(context.Background(), "mySecret", "otherProject", "1")
+	if want := "projects/otherProject/secrets/mySecret/versions/1"; got != want {
+		t.Errorf("GetSecret() name = %v, want %v", got, want)
+	}
+}
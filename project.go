@@ -0,0 +1,184 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	credentialsEnv  = "GOOGLE_APPLICATION_CREDENTIALS"
+	metadataHostEnv = "GCE_METADATA_HOST"
+	metadataIP      = "169.254.169.254"
+	metadataTimeout = 2 * time.Second
+
+	// projectRetryInterval is how long a failed project detection is remembered before it is tried again,
+	// so a metadata server that is not ready yet at startup is asked again later
+	projectRetryInterval = 30 * time.Second
+)
+
+// projectEnvVars are checked in order for a default project
+var projectEnvVars = []string{"GOOGLE_CLOUD_PROJECT", "GCLOUD_PROJECT"}
+
+// ProjectID returns the default project of the client.
+// When WithProjectID was not given the project is detected on first use with DetectProjectID.
+// A failed detection is remembered for projectRetryInterval, so the metadata server is not asked again
+// on every call, unless it failed because ctx was done
+func (c *Client) ProjectID(ctx context.Context) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	c.projectMu.Lock()
+	defer c.projectMu.Unlock()
+
+	if c.projectID != "" {
+		return c.projectID, nil
+	}
+	if c.projectErr != nil && time.Since(c.projectErrAt) < projectRetryInterval {
+		return "", c.projectErr
+	}
+
+	projectID, err := DetectProjectID(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.projectErr, c.projectErrAt = err, time.Now()
+		}
+		return "", err
+	}
+	c.projectID, c.projectErr = projectID, nil
+	return projectID, nil
+}
+
+// DetectProjectID looks up a project from the GOOGLE_CLOUD_PROJECT and GCLOUD_PROJECT environment variables,
+// the application default credentials file and the GCE metadata server, in that order.
+// The metadata server address can be overridden with GCE_METADATA_HOST
+func DetectProjectID(ctx context.Context) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, env := range projectEnvVars {
+		if projectID := os.Getenv(env); projectID != "" {
+			return projectID, nil
+		}
+	}
+
+	if projectID := credentialsProjectID(); projectID != "" {
+		return projectID, nil
+	}
+
+	projectID, err := metadataProjectID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrNoProject, err)
+	}
+	return projectID, nil
+}
+
+// credentialsProjectID reads the project from the application default credentials file, if there is one
+func credentialsProjectID() string {
+	path := os.Getenv(credentialsEnv)
+	if path == "" {
+		path = wellKnownCredentialsFile()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	var creds struct {
+		ProjectID      string `json:"project_id"`
+		QuotaProjectID string `json:"quota_project_id"`
+	}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return ""
+	}
+	if creds.ProjectID != "" {
+		return creds.ProjectID
+	}
+	return creds.QuotaProjectID
+}
+
+// wellKnownCredentialsFile is where gcloud stores application default credentials
+func wellKnownCredentialsFile() string {
+	const f = "application_default_credentials.json"
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "gcloud", f)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gcloud", f)
+}
+
+// metadataProjectID asks the GCE metadata server for the project of the instance
+func metadataProjectID(ctx context.Context) (string, error) {
+	host := os.Getenv(metadataHostEnv)
+	if host == "" {
+		host = metadataIP
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+host+"/computeMetadata/v1/project/project-id", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server returned %v", res.Status)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	projectID := strings.TrimSpace(string(body))
+	if projectID == "" {
+		return "", errors.New("metadata server returned an empty project")
+	}
+	return projectID, nil
+}
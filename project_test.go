@@ -0,0 +1,161 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// clearProjectEnv removes every source of a default project so that each test sets its own
+func clearProjectEnv(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	t.Setenv("GCLOUD_PROJECT", "")
+	t.Setenv(credentialsEnv, filepath.Join(t.TempDir(), "missing.json"))
+	t.Setenv(metadataHostEnv, "127.0.0.1:1")
+}
+
+func TestDetectProjectID(t *testing.T) {
+	detectTest := func(setup func(t *testing.T), want string, wantErr bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			clearProjectEnv(t)
+			setup(t)
+
+			got, err := DetectProjectID(context.Background())
+			if (err != nil) != wantErr {
+				t.Errorf("DetectProjectID() error = %v, wantErr %v", err, wantErr)
+				return
+			}
+			if got != want {
+				t.Errorf("DetectProjectID() got = %v, want %v", got, want)
+			}
+		}
+	}
+
+	t.Run("GoogleCloudProject", detectTest(func(t *testing.T) {
+		t.Setenv("GOOGLE_CLOUD_PROJECT", "envProject")
+		t.Setenv("GCLOUD_PROJECT", "otherProject")
+	}, "envProject", false))
+
+	t.Run("GcloudProject", detectTest(func(t *testing.T) {
+		t.Setenv("GCLOUD_PROJECT", "gcloudProject")
+	}, "gcloudProject", false))
+
+	t.Run("CredentialsFile", detectTest(func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "credentials.json")
+		if err := os.WriteFile(path, []byte(`{"type":"service_account","project_id":"credsProject"}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv(credentialsEnv, path)
+	}, "credsProject", false))
+
+	t.Run("QuotaProject", detectTest(func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "credentials.json")
+		if err := os.WriteFile(path, []byte(`{"type":"authorized_user","quota_project_id":"quotaProject"}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv(credentialsEnv, path)
+	}, "quotaProject", false))
+
+	t.Run("MetadataServer", detectTest(func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/computeMetadata/v1/project/project-id" || r.Header.Get("Metadata-Flavor") != "Google" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte("metadataProject"))
+		}))
+		t.Cleanup(server.Close)
+		t.Setenv(metadataHostEnv, strings.TrimPrefix(server.URL, "http://"))
+	}, "metadataProject", false))
+
+	t.Run("NotFound", detectTest(func(t *testing.T) {}, "", true))
+}
+
+func TestClient_ProjectID(t *testing.T) {
+	clearProjectEnv(t)
+
+	var metadataCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metadataCalls.Add(1)
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv(metadataHostEnv, strings.TrimPrefix(server.URL, "http://"))
+
+	c := &Client{smc: client}
+	if _, err := c.ProjectID(context.Background()); !errors.Is(err, ErrNoProject) {
+		t.Errorf("ProjectID() error = %v, want %v", err, ErrNoProject)
+	}
+	if c.SecretExists(context.Background(), "mySecret", "") {
+		t.Errorf("SecretExists() = true without a project")
+	}
+	// the failed detection is remembered for a while, then tried again
+	if n := metadataCalls.Load(); n != 1 {
+		t.Errorf("asked the metadata server %v times, want 1", n)
+	}
+	c.projectErrAt = c.projectErrAt.Add(-projectRetryInterval)
+	// a nil ctx is tolerated like in the other Client methods
+	if _, err := c.GetSecret(nil, "mySecret", "", ""); !errors.Is(err, ErrNoProject) {
+		t.Errorf("GetSecret() error = %v, want %v", err, ErrNoProject)
+	}
+	if n := metadataCalls.Load(); n != 2 {
+		t.Errorf("asked the metadata server %v times, want 2", n)
+	}
+
+	// a detection cut short by ctx is not remembered
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c = &Client{smc: client}
+	if _, err := c.ProjectID(ctx); err == nil {
+		t.Errorf("ProjectID() error = nil with a cancelled context")
+	}
+	if _, err := c.ProjectID(context.Background()); !errors.Is(err, ErrNoProject) {
+		t.Errorf("ProjectID() error = %v, want %v", err, ErrNoProject)
+	}
+	if n := metadataCalls.Load(); n != 3 {
+		t.Errorf("asked the metadata server %v times, want 3", n)
+	}
+
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "envProject")
+	c = &Client{smc: client}
+	if got, err := c.ProjectID(context.Background()); err != nil || got != "envProject" {
+		t.Errorf("ProjectID() = %v, %v, want %v", got, err, "envProject")
+	}
+
+	c = &Client{smc: client, projectID: "myProject"}
+	if got, err := c.ProjectID(context.Background()); err != nil || got != "myProject" {
+		t.Errorf("ProjectID() = %v, %v, want %v", got, err, "myProject")
+	}
+}
@@ -0,0 +1,62 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// Limiter blocks until a call to Secret Manager may be made, or returns an error when ctx is done first.
+// *rate.Limiter from golang.org/x/time/rate implements it
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// NewLimiter returns a token bucket that allows perSecond calls per second on average and bursts of up to burst calls.
+// It is safe to share between Clients, so that together they stay under the quota of a project
+func NewLimiter(perSecond float64, burst int) Limiter {
+	return rate.NewLimiter(rate.Limit(perSecond), burst)
+}
+
+// WithRateLimits makes calls wait for a limiter before they are made. access limits AccessSecretVersion,
+// which has its own quota, and admin limits all other calls. A nil limiter leaves those calls unlimited
+func WithRateLimits(access Limiter, admin Limiter) Option {
+	return func(c *Client) {
+		c.accessLimiter = access
+		c.adminLimiter = admin
+	}
+}
+
+// limiter returns the limiter for op, or nil
+func (c *Client) limiter(op string) Limiter {
+	if op == "AccessSecretVersion" {
+		return c.accessLimiter
+	}
+	return c.adminLimiter
+}
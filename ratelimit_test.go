@@ -0,0 +1,100 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+)
+
+// countingLimiter counts the calls that waited for it and fails once ctx is done
+type countingLimiter struct {
+	waits int
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.waits++
+	return ctx.Err()
+}
+
+func TestClient_RateLimits(t *testing.T) {
+	access, admin := &countingLimiter{}, &countingLimiter{}
+	c := &Client{smc: client, projectID: "myProject"}
+	WithRateLimits(access, admin)(c)
+
+	AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+		return &pb.AccessSecretVersionResponse{Name: req.Name, Payload: &pb.SecretPayload{}}, nil
+	}
+	GetSecretVersionFunc = func(ctx context.Context, req *pb.GetSecretVersionRequest) (*pb.SecretVersion, error) {
+		return secretVersionPositiveReturn, nil
+	}
+
+	if _, err := c.GetSecret(context.Background(), "mySecret", "", ""); err != nil {
+		t.Fatalf("GetSecret() error = %v", err)
+	}
+	if _, err := c.GetSecretMetadata(context.Background(), "mySecret", "", ""); err != nil {
+		t.Fatalf("GetSecretMetadata() error = %v", err)
+	}
+	if access.waits != 1 || admin.waits != 1 {
+		t.Errorf("access waits = %v, admin waits = %v, want 1 each", access.waits, admin.waits)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.GetSecretMetadata(ctx, "mySecret", "", ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetSecretMetadata() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestNewLimiter_Shared(t *testing.T) {
+	limiter := NewLimiter(0.001, 2)
+	first := &Client{smc: client, projectID: "myProject"}
+	second := &Client{smc: client, projectID: "myProject"}
+	WithRateLimits(nil, limiter)(first)
+	WithRateLimits(nil, limiter)(second)
+
+	GetSecretVersionFunc = func(ctx context.Context, req *pb.GetSecretVersionRequest) (*pb.SecretVersion, error) {
+		return secretVersionPositiveReturn, nil
+	}
+
+	// both clients take from the same burst, so the third call has to wait far longer than its deadline
+	if _, err := first.GetSecretMetadata(context.Background(), "mySecret", "", ""); err != nil {
+		t.Fatalf("GetSecretMetadata() error = %v", err)
+	}
+	if _, err := second.GetSecretMetadata(context.Background(), "mySecret", "", ""); err != nil {
+		t.Fatalf("GetSecretMetadata() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := first.GetSecretMetadata(ctx, "mySecret", "", ""); err == nil {
+		t.Errorf("GetSecretMetadata() succeeded beyond the shared burst")
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+)
+
+var (
+	locationPattern = regexp.MustCompile(`^[a-z]+-[a-z]+[0-9]+$`)
+	kmsKeyPattern   = regexp.MustCompile(`^projects/[^/]+/locations/([^/]+)/keyRings/[^/]+/cryptoKeys/[^/]+$`)
+)
+
+// Replica is a location a user-managed secret is replicated to
+type Replica struct {
+	// Location is a Cloud region such as europe-west1
+	Location string
+	// KMSKeyName optionally encrypts the replica with a customer-managed key in the same location,
+	// projects/*/locations/*/keyRings/*/cryptoKeys/*
+	KMSKeyName string
+}
+
+// AutomaticReplication lets Google choose where the secret is replicated. This is the default.
+// A non-empty kmsKeyName encrypts the secret with a customer-managed key, which must be in the global location
+func AutomaticReplication(kmsKeyName string) SecretOption {
+	return func(s *secretSpec) {
+		automatic := &pb.Replication_Automatic{}
+		if kmsKeyName != "" {
+			if err := validateKMSKey(kmsKeyName, "global"); err != nil {
+				s.fail(err)
+				return
+			}
+			automatic.CustomerManagedEncryption = &pb.CustomerManagedEncryption{KmsKeyName: kmsKeyName}
+		}
+		s.secret.Replication = &pb.Replication{
+			Replication: &pb.Replication_Automatic_{Automatic: automatic},
+		}
+	}
+}
+
+// UserManagedReplication replicates the secret to the given locations only
+func UserManagedReplication(replicas ...Replica) SecretOption {
+	return func(s *secretSpec) {
+		if len(replicas) == 0 {
+			s.fail(fmt.Errorf("%w: user-managed replication needs at least one replica", ErrInvalidOption))
+			return
+		}
+
+		seen := make(map[string]bool, len(replicas))
+		userManaged := &pb.Replication_UserManaged{}
+		for _, r := range replicas {
+			if !locationPattern.MatchString(r.Location) {
+				s.fail(fmt.Errorf("%w: invalid replica location %q", ErrInvalidOption, r.Location))
+				return
+			}
+			if seen[r.Location] {
+				s.fail(fmt.Errorf("%w: duplicate replica location %q", ErrInvalidOption, r.Location))
+				return
+			}
+			seen[r.Location] = true
+
+			replica := &pb.Replication_UserManaged_Replica{Location: r.Location}
+			if r.KMSKeyName != "" {
+				if err := validateKMSKey(r.KMSKeyName, r.Location); err != nil {
+					s.fail(err)
+					return
+				}
+				replica.CustomerManagedEncryption = &pb.CustomerManagedEncryption{KmsKeyName: r.KMSKeyName}
+			}
+			userManaged.Replicas = append(userManaged.Replicas, replica)
+		}
+
+		s.secret.Replication = &pb.Replication{
+			Replication: &pb.Replication_UserManaged_{UserManaged: userManaged},
+		}
+	}
+}
+
+// validateKMSKey checks that keyName is a Cloud KMS key name in location
+func validateKMSKey(keyName string, location string) error {
+	m := kmsKeyPattern.FindStringSubmatch(keyName)
+	if m == nil {
+		return fmt.Errorf("%w: %q is not of the form projects/*/locations/*/keyRings/*/cryptoKeys/*", ErrInvalidOption, keyName)
+	}
+	if !strings.EqualFold(m[1], location) {
+		return fmt.Errorf("%w: key %q is not in location %v", ErrInvalidOption, keyName, location)
+	}
+	return nil
+}
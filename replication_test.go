@@ -0,0 +1,107 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestClient_CreateSecretReplication(t *testing.T) {
+	replicationTest := func(opt SecretOption, want *pb.Replication, wantErr bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			c := &Client{smc: client, projectID: "myProject"}
+
+			var got *pb.CreateSecretRequest
+			CreateSecretFunc = func(ctx context.Context, req *pb.CreateSecretRequest) (*pb.Secret, error) {
+				got = req
+				return req.Secret, nil
+			}
+
+			_, err := c.CreateSecret(context.Background(), SecretName{Secret: "mySecret"}, opt)
+			if (err != nil) != wantErr {
+				t.Errorf("CreateSecret() error = %v, wantErr %v", err, wantErr)
+				return
+			}
+			if wantErr {
+				if !errors.Is(err, ErrInvalidOption) {
+					t.Errorf("CreateSecret() error = %v, want %v", err, ErrInvalidOption)
+				}
+				if got != nil {
+					t.Errorf("CreateSecret() called Secret Manager with an invalid option")
+				}
+				return
+			}
+			if !proto.Equal(got.Secret.Replication, want) {
+				t.Errorf("CreateSecret() replication = %v, want %v", got.Secret.Replication, want)
+			}
+		}
+	}
+
+	t.Run("Automatic", replicationTest(AutomaticReplication(""), &pb.Replication{
+		Replication: &pb.Replication_Automatic_{Automatic: &pb.Replication_Automatic{}},
+	}, false))
+
+	t.Run("AutomaticCMEK", replicationTest(AutomaticReplication("projects/p/locations/global/keyRings/r/cryptoKeys/k"), &pb.Replication{
+		Replication: &pb.Replication_Automatic_{Automatic: &pb.Replication_Automatic{
+			CustomerManagedEncryption: &pb.CustomerManagedEncryption{KmsKeyName: "projects/p/locations/global/keyRings/r/cryptoKeys/k"},
+		}},
+	}, false))
+
+	t.Run("UserManaged", replicationTest(UserManagedReplication(
+		Replica{Location: "europe-west1", KMSKeyName: "projects/p/locations/europe-west1/keyRings/r/cryptoKeys/k"},
+		Replica{Location: "europe-west4"},
+	), &pb.Replication{
+		Replication: &pb.Replication_UserManaged_{UserManaged: &pb.Replication_UserManaged{
+			Replicas: []*pb.Replication_UserManaged_Replica{
+				{
+					Location:                  "europe-west1",
+					CustomerManagedEncryption: &pb.CustomerManagedEncryption{KmsKeyName: "projects/p/locations/europe-west1/keyRings/r/cryptoKeys/k"},
+				},
+				{Location: "europe-west4"},
+			},
+		}},
+	}, false))
+
+	t.Run("AutomaticRegionalKey", replicationTest(AutomaticReplication("projects/p/locations/europe-west1/keyRings/r/cryptoKeys/k"), nil, true))
+	t.Run("NoReplicas", replicationTest(UserManagedReplication(), nil, true))
+	t.Run("BadLocation", replicationTest(UserManagedReplication(Replica{Location: "Europe"}), nil, true))
+	t.Run("DuplicateLocation", replicationTest(UserManagedReplication(Replica{Location: "us-east1"}, Replica{Location: "us-east1"}), nil, true))
+	t.Run("BadKeyName", replicationTest(UserManagedReplication(Replica{Location: "us-east1", KMSKeyName: "my-key"}), nil, true))
+	t.Run("KeyInOtherLocation", replicationTest(UserManagedReplication(Replica{Location: "us-east1", KMSKeyName: "projects/p/locations/us-west1/keyRings/r/cryptoKeys/k"}), nil, true))
+}
+
+func TestClient_UpdateSecretReplication(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+	if _, err := c.UpdateSecret(context.Background(), SecretName{Secret: "mySecret"}, UserManagedReplication(Replica{Location: "us-east1"})); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("UpdateSecret() error = %v, want %v", err, ErrInvalidOption)
+	}
+}
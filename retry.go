@@ -0,0 +1,139 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy configures how failed calls are retried. Zero fields take the values of DefaultRetryPolicy.
+// Only calls that read, and so can safely be repeated, are retried; AddSecretVersion, CreateSecret and other
+// writes are made once
+type RetryPolicy struct {
+	// MaxAttempts is the most times a call is made, including the first
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry. It doubles with every retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between retries
+	MaxBackoff time.Duration
+	// Jitter randomly shortens or lengthens each wait by up to this fraction
+	Jitter float64
+	// Codes are the gRPC codes of failures that are retried
+	Codes []codes.Code
+}
+
+// DefaultRetryPolicy returns the policy used for the zero fields of a RetryPolicy passed to WithRetry
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Jitter:         0.2,
+		Codes:          []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded, codes.Aborted},
+	}
+}
+
+// WithRetry retries calls that fail with a transient error according to policy. Without it calls are made once
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		defaults := DefaultRetryPolicy()
+		if policy.MaxAttempts <= 0 {
+			policy.MaxAttempts = defaults.MaxAttempts
+		}
+		if policy.InitialBackoff <= 0 {
+			policy.InitialBackoff = defaults.InitialBackoff
+		}
+		if policy.MaxBackoff <= 0 {
+			policy.MaxBackoff = defaults.MaxBackoff
+		}
+		if policy.Jitter <= 0 {
+			policy.Jitter = defaults.Jitter
+		}
+		if len(policy.Codes) == 0 {
+			policy.Codes = defaults.Codes
+		}
+		c.retry = &policy
+	}
+}
+
+// idempotentOps are the SecretClient calls that are retried, since making them again has no further effect
+var idempotentOps = map[string]bool{
+	"AccessSecretVersion": true,
+	"GetSecret":           true,
+	"GetSecretVersion":    true,
+	"ListSecrets":         true,
+	"ListSecretVersions":  true,
+}
+
+// attempts returns how many times op may be made
+func (p *RetryPolicy) attempts(op string) int {
+	if p == nil || !idempotentOps[op] {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// retryable reports whether a call that failed with err may be retried
+func (p *RetryPolicy) retryable(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		// retrying would fail just as fast
+		return false
+	}
+	code := status.Code(err)
+	var secretErr *SecretError
+	if errors.As(err, &secretErr) {
+		code = secretErr.Code
+	}
+	return slices.Contains(p.Codes, code)
+}
+
+// backoff returns the wait before retry number retry, counting from 1
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < retry && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	return jitter(min(d, p.MaxBackoff), p.Jitter)
+}
+
+// wait sleeps for d, returning false early if ctx is done
+func wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
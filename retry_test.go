@@ -0,0 +1,151 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClient_Retry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Nanosecond, MaxBackoff: time.Nanosecond}
+
+	retryTest := func(call func(c *Client) error, failures []error, wantCalls int, wantAttempts int) func(t *testing.T) {
+		return func(t *testing.T) {
+			c := &Client{smc: client, projectID: "myProject"}
+			WithRetry(policy)(c)
+
+			calls := 0
+			fail := func() error {
+				calls++
+				if calls <= len(failures) {
+					return failures[calls-1]
+				}
+				return nil
+			}
+			AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+				if err := fail(); err != nil {
+					return nil, err
+				}
+				return &pb.AccessSecretVersionResponse{Name: req.Name, Payload: &pb.SecretPayload{Data: []byte("data")}}, nil
+			}
+			GetSecretFunc = func(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+				if err := fail(); err != nil {
+					return nil, err
+				}
+				return secretPositiveReturn, nil
+			}
+			AddSecretVersionFunc = func(ctx context.Context, req *pb.AddSecretVersionRequest) (*pb.SecretVersion, error) {
+				if err := fail(); err != nil {
+					return nil, err
+				}
+				return secretVersionPositiveReturn, nil
+			}
+
+			err := call(c)
+			if calls != wantCalls {
+				t.Errorf("made %v calls, want %v", calls, wantCalls)
+			}
+			var secretErr *SecretError
+			if wantAttempts == 0 {
+				if err != nil {
+					t.Errorf("error = %v, want success", err)
+				}
+				return
+			}
+			if !errors.As(err, &secretErr) || secretErr.Attempts != wantAttempts {
+				t.Errorf("error = %#v, want *SecretError after %v attempts", err, wantAttempts)
+			}
+		}
+	}
+
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	getSecret := func(c *Client) error {
+		_, err := c.GetSecret(context.Background(), "mySecret", "", "")
+		return err
+	}
+
+	t.Run("RecoversGetSecret", retryTest(getSecret, []error{unavailable, unavailable}, 3, 0))
+	t.Run("GivesUp", retryTest(getSecret, []error{unavailable, unavailable, unavailable, unavailable}, 3, 3))
+	t.Run("NotRetryable", retryTest(getSecret, []error{status.Error(codes.NotFound, "not found")}, 1, 1))
+	t.Run("RecoversLookupSecret", retryTest(func(c *Client) error {
+		_, err := c.CheckSecretExists(context.Background(), SecretName{Secret: "mySecret"})
+		return err
+	}, []error{status.Error(codes.ResourceExhausted, "quota")}, 2, 0))
+	t.Run("NeverRetriesAddVersion", retryTest(func(c *Client) error {
+		_, err := c.AddNewSecretVersion(context.Background(), "mySecret", "", []byte("data"))
+		return err
+	}, []error{unavailable}, 1, 1))
+}
+
+func TestClient_RetryStopsWithContext(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+	WithRetry(RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour})(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	AccessSecretVersionFunc = func(_ context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+		calls++
+		cancel()
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	}
+
+	_, err := c.GetSecret(ctx, "mySecret", "", "")
+	if calls != 1 || err == nil {
+		t.Errorf("GetSecret() made %v calls with error %v, want to stop waiting when ctx is done", calls, err)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	c := &Client{}
+	WithRetry(RetryPolicy{MaxAttempts: 6})(c)
+	p := c.retry
+	p.Jitter = 0
+
+	if p.InitialBackoff != DefaultRetryPolicy().InitialBackoff || len(p.Codes) == 0 {
+		t.Errorf("WithRetry() did not fill in defaults: %+v", p)
+	}
+	var backoffs []string
+	for retry := 1; retry <= 8; retry++ {
+		backoffs = append(backoffs, p.backoff(retry).String())
+	}
+	if got, want := strings.Join(backoffs, " "), "100ms 200ms 400ms 800ms 1.6s 3.2s 5s 5s"; got != want {
+		t.Errorf("backoffs = %v, want %v", got, want)
+	}
+
+	err := &SecretError{Op: "AccessSecretVersion", Name: "projects/p/secrets/s/versions/latest", Code: codes.Unavailable, Err: errors.New("unavailable"), Attempts: 3}
+	if got, want := err.Error(), "gsm: AccessSecretVersion projects/p/secrets/s/versions/latest: unavailable (after 3 attempts)"; got != want {
+		t.Errorf("Error() = %v, want %v", got, want)
+	}
+}
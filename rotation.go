@@ -0,0 +1,121 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// minRotationPeriod is the shortest rotation period Secret Manager accepts
+const minRotationPeriod = time.Hour
+
+var topicPattern = regexp.MustCompile(`^projects/[^/]+/topics/[^/]+$`)
+
+// Rotation is the rotation schedule of a secret and the Pub/Sub topics notified about it
+type Rotation struct {
+	// Period is the time between rotations, zero if the secret is not rotated periodically
+	Period time.Duration
+	// NextRotationTime is when the next rotation notification is sent, zero if none is scheduled
+	NextRotationTime time.Time
+	// Topics are the Pub/Sub topics notified about rotations and other changes of the secret
+	Topics []string
+}
+
+// SecretRotationPeriod makes Secret Manager send a rotation notification every period, which must be at least an hour.
+// Rotation needs SecretTopics and, when the secret is created, SecretNextRotationTime
+func SecretRotationPeriod(period time.Duration) SecretOption {
+	return func(s *secretSpec) {
+		if period < minRotationPeriod {
+			s.fail(fmt.Errorf("%w: rotation period must be at least %v, got %v", ErrInvalidOption, minRotationPeriod, period))
+			return
+		}
+		s.rotation().RotationPeriod = durationpb.New(period)
+		s.update("rotation.rotation_period")
+	}
+}
+
+// SecretNextRotationTime makes Secret Manager send the next rotation notification at t
+func SecretNextRotationTime(t time.Time) SecretOption {
+	return func(s *secretSpec) {
+		if t.IsZero() {
+			s.fail(fmt.Errorf("%w: next rotation time must be set", ErrInvalidOption))
+			return
+		}
+		s.rotation().NextRotationTime = timestamppb.New(t)
+		s.update("rotation.next_rotation_time")
+	}
+}
+
+// SecretTopics replaces the Pub/Sub topics notified about changes to the secret, projects/*/topics/*
+func SecretTopics(topics ...string) SecretOption {
+	return func(s *secretSpec) {
+		s.secret.Topics = make([]*pb.Topic, 0, len(topics))
+		for _, topic := range topics {
+			if !topicPattern.MatchString(topic) {
+				s.fail(fmt.Errorf("%w: %q is not of the form projects/*/topics/*", ErrInvalidOption, topic))
+				return
+			}
+			s.secret.Topics = append(s.secret.Topics, &pb.Topic{Name: topic})
+		}
+		s.update("topics")
+	}
+}
+
+// rotation returns the rotation of the secret being built, creating it if needed
+func (s *secretSpec) rotation() *pb.Rotation {
+	if s.secret.Rotation == nil {
+		s.secret.Rotation = &pb.Rotation{}
+	}
+	return s.secret.Rotation
+}
+
+// SecretRotation returns the rotation schedule and topics of a secret
+func (c *Client) SecretRotation(ctx context.Context, name SecretName) (*Rotation, error) {
+	secret, err := c.LookupSecret(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	rotation := &Rotation{}
+	if period := secret.GetRotation().GetRotationPeriod(); period != nil {
+		rotation.Period = period.AsDuration()
+	}
+	if next := secret.GetRotation().GetNextRotationTime(); next != nil {
+		rotation.NextRotationTime = next.AsTime()
+	}
+	for _, topic := range secret.GetTopics() {
+		rotation.Topics = append(rotation.Topics, topic.GetName())
+	}
+	return rotation, nil
+}
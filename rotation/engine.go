@@ -0,0 +1,309 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+// Package rotation rotates credentials stored in Secret Manager. A Rotator is registered per secret;
+// the Engine stores each new credential as a secret version, verifies it and disables the previous
+// version, optionally after a grace period, rolling back when any step fails.
+package rotation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	gsm "github.com/kioie/gcp-secret-manager"
+)
+
+var (
+	// ErrNotRegistered is returned when rotating a secret that has no Rotator
+	ErrNotRegistered = errors.New("rotation: secret is not registered")
+	// ErrInProgress is returned when a rotation of the same secret is already running
+	ErrInProgress = errors.New("rotation: rotation already in progress")
+	// ErrShutdown is reported for previous versions left enabled because Shutdown gave up waiting for their grace period
+	ErrShutdown = errors.New("rotation: engine shut down during the grace period")
+)
+
+// Client is the part of *gsm.Client used by the Engine
+type Client interface {
+	AccessSecretVersion(ctx context.Context, name gsm.VersionName) (*pb.AccessSecretVersionResponse, error)
+	AddNewSecretVersion(ctx context.Context, secretName string, projectId string, payload []byte) (*pb.SecretVersion, error)
+	DisableSecret(ctx context.Context, secretName string, projectId string, version string) (*pb.SecretVersion, error)
+	ProjectID(ctx context.Context) (string, error)
+	Invalidate(ctx context.Context, name gsm.SecretName) error
+}
+
+// Rotator rotates the credential stored in one secret
+type Rotator struct {
+	// Generate creates a new credential. current is the payload of the latest version, nil if the secret has none
+	Generate func(ctx context.Context, current []byte) ([]byte, error)
+	// Apply makes the downstream system accept the new credential. Optional
+	Apply func(ctx context.Context, next []byte) error
+	// Verify checks that the new credential works. Optional
+	Verify func(ctx context.Context, next []byte) error
+	// Revert makes the downstream system accept the previous credential again after Apply or Verify failed.
+	// previous is nil if the secret had no version. Optional
+	Revert func(ctx context.Context, previous []byte) error
+}
+
+// Option configures an Engine created by New
+type Option func(*Engine)
+
+// WithGracePeriod sets how long the previous version stays enabled after a rotation,
+// so consumers that still hold it can switch over. The default is no grace period.
+// Rotations do not wait for it: the previous version is disabled in the background once it has passed,
+// and a failure to do so is passed to the WithErrorHandler function. Call Shutdown before exiting
+// to wait for these
+func WithGracePeriod(d time.Duration) Option {
+	return func(e *Engine) {
+		e.grace = d
+	}
+}
+
+// WithErrorHandler sets a function called with the errors of rotations started by Run
+// and of disabling previous versions after the grace period
+func WithErrorHandler(fn func(name gsm.SecretName, err error)) Option {
+	return func(e *Engine) {
+		e.onError = fn
+	}
+}
+
+// Engine rotates registered secrets on demand, on a schedule or on rotation notifications
+type Engine struct {
+	client  Client
+	grace   time.Duration
+	onError func(name gsm.SecretName, err error)
+	tick    time.Duration
+	ticker  func(d time.Duration) (<-chan time.Time, func())
+	now     func() time.Time
+	after   func(d time.Duration) <-chan time.Time
+
+	mu            sync.Mutex
+	registrations map[gsm.SecretName]*registration
+	abandoned     []error
+
+	// followUps disable previous versions after the grace period until stop is closed by Shutdown
+	followUps sync.WaitGroup
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+type registration struct {
+	rotator Rotator
+	every   time.Duration
+	next    time.Time
+	running sync.Mutex
+}
+
+// New returns an Engine that rotates secrets through client
+func New(client Client, opts ...Option) *Engine {
+	e := &Engine{
+		client:        client,
+		tick:          time.Minute,
+		ticker:        newTicker,
+		now:           time.Now,
+		after:         time.After,
+		registrations: make(map[gsm.SecretName]*registration),
+		stop:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Register sets the Rotator of a secret. A positive every makes Run rotate the secret at that interval,
+// otherwise it is only rotated by Rotate and HandleNotification. An empty Project means the default project
+func (e *Engine) Register(name gsm.SecretName, every time.Duration, r Rotator) error {
+	if name.Secret == "" {
+		return fmt.Errorf("%w: empty secret id", gsm.ErrInvalidName)
+	}
+	if r.Generate == nil {
+		return fmt.Errorf("rotation: %v: Rotator.Generate is required", name.Secret)
+	}
+
+	reg := &registration{rotator: r, every: every}
+	if every > 0 {
+		reg.next = e.now().Add(every)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.registrations[name] = reg
+	return nil
+}
+
+// Rotate rotates a registered secret now. It adds the new credential as a version, applies and verifies it,
+// and disables the previous version, after the grace period if one is set. When applying or verifying fails
+// the new version is disabled, the previous credential is reverted and added back as the latest version
+func (e *Engine) Rotate(ctx context.Context, name gsm.SecretName) error {
+	e.mu.Lock()
+	reg, ok := e.registrations[name]
+	e.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrNotRegistered, name.Secret)
+	}
+	if !reg.running.TryLock() {
+		return fmt.Errorf("%w: %v", ErrInProgress, name.Secret)
+	}
+	defer reg.running.Unlock()
+
+	err := e.rotate(ctx, name, reg.rotator)
+	if reg.every > 0 {
+		e.mu.Lock()
+		reg.next = e.now().Add(reg.every)
+		e.mu.Unlock()
+	}
+	return err
+}
+
+func (e *Engine) rotate(ctx context.Context, name gsm.SecretName, r Rotator) error {
+	// a cached latest version may be older than the one the new credential has to replace
+	if err := e.client.Invalidate(ctx, name); err != nil {
+		return fmt.Errorf("rotation: %v: %w", name.Secret, err)
+	}
+	previous, err := e.client.AccessSecretVersion(ctx, name.Version(gsm.LatestVersion))
+	if err != nil && !errors.Is(err, gsm.ErrNotFound) {
+		return fmt.Errorf("rotation: %v: reading current version: %w", name.Secret, err)
+	}
+	current := previous.GetPayload().GetData()
+
+	next, err := r.Generate(ctx, current)
+	if err != nil {
+		return fmt.Errorf("rotation: %v: generate: %w", name.Secret, err)
+	}
+
+	added, err := e.client.AddNewSecretVersion(ctx, name.Secret, name.Project, next)
+	if err != nil {
+		return fmt.Errorf("rotation: %v: adding version: %w", name.Secret, err)
+	}
+
+	if err := e.applyAndVerify(ctx, r, next); err != nil {
+		err = fmt.Errorf("rotation: %v: %w", name.Secret, err)
+		return errors.Join(err, e.rollback(ctx, name, r, added, current, previous != nil))
+	}
+
+	if previous == nil {
+		return nil
+	}
+	version := versionID(previous.GetName())
+	if e.grace <= 0 {
+		return e.disablePrevious(ctx, name, version)
+	}
+	e.followUps.Add(1)
+	go func() {
+		defer e.followUps.Done()
+		select {
+		case <-e.after(e.grace):
+			e.report(name, e.disablePrevious(context.WithoutCancel(ctx), name, version))
+		case <-e.stop:
+			err := fmt.Errorf("rotation: %v: previous version %v left enabled: %w", name.Secret, version, ErrShutdown)
+			e.mu.Lock()
+			e.abandoned = append(e.abandoned, err)
+			e.mu.Unlock()
+			e.report(name, err)
+		}
+	}()
+	return nil
+}
+
+// Shutdown waits until the previous versions of finished rotations are disabled after their grace period,
+// or until ctx is done. The versions still waiting then are left enabled and reported, as errors matching
+// ErrShutdown, to the WithErrorHandler function and in the returned error. Rotations started after
+// Shutdown leave their previous version enabled at once when they have a grace period
+func (e *Engine) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		e.followUps.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		e.stopOnce.Do(func() { close(e.stop) })
+		<-done
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	err := errors.Join(e.abandoned...)
+	e.abandoned = nil
+	return err
+}
+
+// disablePrevious disables the version that was latest before a rotation
+func (e *Engine) disablePrevious(ctx context.Context, name gsm.SecretName, version string) error {
+	if _, err := e.client.DisableSecret(ctx, name.Secret, name.Project, version); err != nil {
+		return fmt.Errorf("rotation: %v: disabling previous version: %w", name.Secret, err)
+	}
+	return nil
+}
+
+func (e *Engine) applyAndVerify(ctx context.Context, r Rotator, next []byte) error {
+	if r.Apply != nil {
+		if err := r.Apply(ctx, next); err != nil {
+			return fmt.Errorf("apply: %w", err)
+		}
+	}
+	if r.Verify != nil {
+		if err := r.Verify(ctx, next); err != nil {
+			return fmt.Errorf("verify: %w", err)
+		}
+	}
+	return nil
+}
+
+// rollback undoes a failed rotation. The previous credential is added back as a new version,
+// since latest keeps pointing at the disabled version otherwise
+func (e *Engine) rollback(ctx context.Context, name gsm.SecretName, r Rotator, added *pb.SecretVersion, current []byte, hadPrevious bool) error {
+	var errs []error
+	if r.Revert != nil {
+		if err := r.Revert(ctx, current); err != nil {
+			errs = append(errs, fmt.Errorf("rotation: %v: revert: %w", name.Secret, err))
+		}
+	}
+	if _, err := e.client.DisableSecret(ctx, name.Secret, name.Project, versionID(added.GetName())); err != nil {
+		errs = append(errs, fmt.Errorf("rotation: %v: disabling new version: %w", name.Secret, err))
+	}
+	if hadPrevious {
+		if _, err := e.client.AddNewSecretVersion(ctx, name.Secret, name.Project, current); err != nil {
+			errs = append(errs, fmt.Errorf("rotation: %v: restoring previous version: %w", name.Secret, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// versionID returns the version id of a version resource name
+func versionID(resource string) string {
+	name, err := gsm.ParseVersionName(resource)
+	if err != nil {
+		return resource
+	}
+	return name.Version
+}
@@ -0,0 +1,286 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package rotation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	gsm "github.com/kioie/gcp-secret-manager"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeSecret keeps the versions of one secret behind the gsm mock funcs
+type fakeSecret struct {
+	mu       sync.Mutex
+	versions []*pb.SecretVersion
+	payloads [][]byte
+}
+
+func newFakeSecret(t *testing.T, payloads ...string) (*fakeSecret, *gsm.Client) {
+	f := &fakeSecret{}
+	for _, p := range payloads {
+		f.add([]byte(p))
+	}
+
+	gsm.AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if len(f.versions) == 0 {
+			return nil, status.Error(codes.NotFound, "no versions")
+		}
+		v := f.versions[len(f.versions)-1]
+		if v.State != pb.SecretVersion_ENABLED {
+			return nil, status.Errorf(codes.FailedPrecondition, "%v is in DISABLED state", v.Name)
+		}
+		return &pb.AccessSecretVersionResponse{Name: v.Name, Payload: &pb.SecretPayload{Data: f.payloads[len(f.payloads)-1]}}, nil
+	}
+	gsm.AddSecretVersionFunc = func(ctx context.Context, req *pb.AddSecretVersionRequest) (*pb.SecretVersion, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.add(req.Payload.Data), nil
+	}
+	gsm.DisableSecretVersionFunc = func(ctx context.Context, req *pb.DisableSecretVersionRequest) (*pb.SecretVersion, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for _, v := range f.versions {
+			if v.Name == req.Name {
+				v.State = pb.SecretVersion_DISABLED
+				return v, nil
+			}
+		}
+		return nil, status.Error(codes.NotFound, "no such version")
+	}
+
+	c, err := gsm.NewClient(context.Background(), gsm.WithSecretClient(&gsm.MockClient{}), gsm.WithProjectID("myProject"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return f, c
+}
+
+func (f *fakeSecret) add(payload []byte) *pb.SecretVersion {
+	v := &pb.SecretVersion{
+		Name:  fmt.Sprintf("projects/myProject/secrets/db/versions/%d", len(f.versions)+1),
+		State: pb.SecretVersion_ENABLED,
+	}
+	f.versions = append(f.versions, v)
+	f.payloads = append(f.payloads, payload)
+	return v
+}
+
+// states returns payload:state of every version, oldest first
+func (f *fakeSecret) states() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var s []string
+	for i, v := range f.versions {
+		s = append(s, fmt.Sprintf("%s:%v", f.payloads[i], v.State))
+	}
+	return strings.Join(s, " ")
+}
+
+func TestEngine_Rotate(t *testing.T) {
+	rotateTest := func(initial []string, r Rotator, wantErr bool, wantStates string) func(t *testing.T) {
+		return func(t *testing.T) {
+			f, c := newFakeSecret(t, initial...)
+			e := New(c)
+			name := gsm.SecretName{Secret: "db"}
+			if err := e.Register(name, 0, r); err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+
+			err := e.Rotate(context.Background(), name)
+			if (err != nil) != wantErr {
+				t.Errorf("Rotate() error = %v, wantErr %v", err, wantErr)
+			}
+			if got := f.states(); got != wantStates {
+				t.Errorf("versions = %v, want %v", got, wantStates)
+			}
+		}
+	}
+
+	var applied []byte
+	next := func(ctx context.Context, current []byte) ([]byte, error) {
+		return append(bytes.Clone(current), '+'), nil
+	}
+
+	t.Run("Success", rotateTest([]string{"pw"}, Rotator{
+		Generate: next,
+		Apply: func(ctx context.Context, next []byte) error {
+			applied = next
+			return nil
+		},
+		Verify: func(ctx context.Context, next []byte) error {
+			if !bytes.Equal(next, applied) {
+				return errors.New("not applied")
+			}
+			return nil
+		},
+	}, false, "pw:DISABLED pw+:ENABLED"))
+
+	t.Run("FirstVersion", rotateTest(nil, Rotator{Generate: next}, false, "+:ENABLED"))
+
+	t.Run("GenerateFails", rotateTest([]string{"pw"}, Rotator{
+		Generate: func(ctx context.Context, current []byte) ([]byte, error) {
+			return nil, errors.New("generator down")
+		},
+	}, true, "pw:ENABLED"))
+
+	var reverted []byte
+	t.Run("VerifyFailsRollsBack", rotateTest([]string{"pw"}, Rotator{
+		Generate: next,
+		Verify: func(ctx context.Context, next []byte) error {
+			return errors.New("login failed")
+		},
+		Revert: func(ctx context.Context, previous []byte) error {
+			reverted = previous
+			return nil
+		},
+	}, true, "pw:ENABLED pw+:DISABLED pw:ENABLED"))
+	if string(reverted) != "pw" {
+		t.Errorf("Revert() got %q, want %q", reverted, "pw")
+	}
+}
+
+func TestEngine_RotateBypassesCache(t *testing.T) {
+	f, _ := newFakeSecret(t, "pw")
+	c, err := gsm.NewClient(context.Background(), gsm.WithSecretClient(&gsm.MockClient{}), gsm.WithProjectID("myProject"), gsm.WithCache())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	name := gsm.SecretName{Secret: "db"}
+
+	// latest is cached, then another writer adds a version
+	if _, err := c.AccessSecretVersion(context.Background(), name.Version(gsm.LatestVersion)); err != nil {
+		t.Fatalf("AccessSecretVersion() error = %v", err)
+	}
+	f.mu.Lock()
+	f.add([]byte("pw2"))
+	f.mu.Unlock()
+
+	var current []byte
+	e := New(c)
+	_ = e.Register(name, 0, Rotator{Generate: func(ctx context.Context, cur []byte) ([]byte, error) {
+		current = cur
+		return []byte("new"), nil
+	}})
+	if err := e.Rotate(context.Background(), name); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if string(current) != "pw2" {
+		t.Errorf("Generate() got %q, want the latest %q", current, "pw2")
+	}
+	if got, want := f.states(), "pw:ENABLED pw2:DISABLED new:ENABLED"; got != want {
+		t.Errorf("versions = %v, want %v", got, want)
+	}
+}
+
+func TestEngine_RotateNotRegistered(t *testing.T) {
+	_, c := newFakeSecret(t)
+	if err := New(c).Rotate(context.Background(), gsm.SecretName{Secret: "db"}); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("Rotate() error = %v, want %v", err, ErrNotRegistered)
+	}
+}
+
+func TestEngine_GracePeriod(t *testing.T) {
+	f, c := newFakeSecret(t, "pw")
+	e := New(c, WithGracePeriod(time.Hour), WithErrorHandler(func(name gsm.SecretName, err error) {
+		t.Errorf("rotation of %v failed: %v", name, err)
+	}))
+	grace := make(chan time.Time)
+	e.after = func(d time.Duration) <-chan time.Time {
+		if d != time.Hour {
+			t.Errorf("grace period = %v, want %v", d, time.Hour)
+		}
+		return grace
+	}
+
+	name := gsm.SecretName{Secret: "db"}
+	_ = e.Register(name, 0, Rotator{Generate: func(ctx context.Context, current []byte) ([]byte, error) {
+		return append(bytes.Clone(current), '+'), nil
+	}})
+
+	// Rotate returns while the previous version is still enabled during the grace period
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := e.Rotate(ctx, name); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	cancel()
+	if got, want := f.states(), "pw:ENABLED pw+:ENABLED"; got != want {
+		t.Errorf("versions = %v, want %v", got, want)
+	}
+
+	go func() { grace <- time.Now() }()
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+	if got, want := f.states(), "pw:DISABLED pw+:ENABLED"; got != want {
+		t.Errorf("versions = %v, want %v", got, want)
+	}
+}
+
+func TestEngine_ShutdownAbandons(t *testing.T) {
+	f, c := newFakeSecret(t, "pw")
+	var reported []error
+	e := New(c, WithGracePeriod(time.Hour), WithErrorHandler(func(name gsm.SecretName, err error) {
+		reported = append(reported, err)
+	}))
+	e.after = func(d time.Duration) <-chan time.Time {
+		return make(chan time.Time)
+	}
+
+	name := gsm.SecretName{Secret: "db"}
+	_ = e.Register(name, 0, Rotator{Generate: func(ctx context.Context, current []byte) ([]byte, error) {
+		return append(bytes.Clone(current), '+'), nil
+	}})
+	if err := e.Rotate(context.Background(), name); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+
+	// the process shuts down during the grace period
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := e.Shutdown(ctx)
+	if !errors.Is(err, ErrShutdown) || !strings.Contains(err.Error(), "previous version 1 left enabled") {
+		t.Errorf("Shutdown() error = %v, want version 1 reported with %v", err, ErrShutdown)
+	}
+	if len(reported) != 1 || !errors.Is(reported[0], ErrShutdown) {
+		t.Errorf("reported errors = %v, want one matching %v", reported, ErrShutdown)
+	}
+	if got, want := f.states(), "pw:ENABLED pw+:ENABLED"; got != want {
+		t.Errorf("versions = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package rotation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	gsm "github.com/kioie/gcp-secret-manager"
+)
+
+// Run rotates registered secrets whenever their interval has passed, until ctx is done.
+// Errors are passed to the WithErrorHandler function. Run waits for running rotations before it returns ctx.Err()
+func (e *Engine) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticks, stop := e.ticker(e.tick)
+	defer stop()
+	for {
+		for _, name := range e.due() {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := e.Rotate(ctx, name); !errors.Is(err, ErrInProgress) {
+					e.report(name, err)
+				}
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticks:
+		}
+	}
+}
+
+// due returns the scheduled secrets whose next rotation time has passed. Their next rotation time is moved
+// an interval ahead, so later ticks do not start them again while they are rotated
+func (e *Engine) due() []gsm.SecretName {
+	now := e.now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var names []gsm.SecretName
+	for name, reg := range e.registrations {
+		if reg.every > 0 && !now.Before(reg.next) {
+			reg.next = now.Add(reg.every)
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// newTicker returns the channel of a time.Ticker and the function that stops it
+func newTicker(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// HandleNotification rotates the secret named by the attributes of a Secret Manager Pub/Sub notification.
+// Events other than SECRET_ROTATE, unregistered secrets and secrets already being rotated are ignored
+func (e *Engine) HandleNotification(ctx context.Context, attributes map[string]string) error {
+	n, err := gsm.ParseNotification(attributes)
+	if err != nil {
+		return fmt.Errorf("rotation: notification: %w", err)
+	}
+	if n.EventType != gsm.EventSecretRotate {
+		return nil
+	}
+
+	name, ok, err := e.lookup(ctx, n.Secret)
+	if err != nil || !ok {
+		return err
+	}
+	if err := e.Rotate(ctx, name); !errors.Is(err, ErrInProgress) {
+		return err
+	}
+	return nil
+}
+
+// lookup finds the registration of a secret named in a notification, which is registered either
+// under its project or without a project if it is in the default project
+func (e *Engine) lookup(ctx context.Context, name gsm.SecretName) (gsm.SecretName, bool, error) {
+	e.mu.Lock()
+	_, ok := e.registrations[name]
+	_, okDefault := e.registrations[gsm.SecretName{Secret: name.Secret}]
+	e.mu.Unlock()
+
+	if ok {
+		return name, true, nil
+	}
+	if !okDefault {
+		return name, false, nil
+	}
+	project, err := e.client.ProjectID(ctx)
+	if err != nil {
+		return name, false, err
+	}
+	if project != name.Project {
+		return name, false, nil
+	}
+	return gsm.SecretName{Secret: name.Secret}, true, nil
+}
+
+func (e *Engine) report(name gsm.SecretName, err error) {
+	if err != nil && e.onError != nil {
+		e.onError(name, err)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package rotation
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	gsm "github.com/kioie/gcp-secret-manager"
+)
+
+func TestEngine_Run(t *testing.T) {
+	f, c := newFakeSecret(t, "pw")
+
+	var mu sync.Mutex
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+
+	e := New(c, WithErrorHandler(func(name gsm.SecretName, err error) {
+		t.Errorf("rotation of %v failed: %v", name, err)
+	}))
+	e.now = clock
+	ticks := make(chan time.Time)
+	e.ticker = func(d time.Duration) (<-chan time.Time, func()) {
+		return ticks, func() {}
+	}
+	// every send returns once Run is waiting for the next tick, so the secrets due at the previous tick were started
+	tick := func() { ticks <- time.Time{} }
+
+	rotations := make(chan struct{}, 10)
+	release := make(chan struct{})
+	name := gsm.SecretName{Secret: "db"}
+	_ = e.Register(name, 24*time.Hour, Rotator{Generate: func(ctx context.Context, current []byte) ([]byte, error) {
+		rotations <- struct{}{}
+		<-release
+		return append(current, '+'), nil
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- e.Run(ctx) }()
+
+	tick()
+	tick()
+
+	mu.Lock()
+	now = now.Add(24 * time.Hour)
+	mu.Unlock()
+	tick()
+	<-rotations
+
+	// ticks during the rotation do not start it again
+	tick()
+	tick()
+	if err := e.Rotate(context.Background(), name); !errors.Is(err, ErrInProgress) {
+		t.Errorf("concurrent Rotate() error = %v, want %v", err, ErrInProgress)
+	}
+	close(release)
+	for f.states() != "pw:DISABLED pw+:ENABLED" {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the next rotation is a day after this one
+	mu.Lock()
+	now = now.Add(time.Hour)
+	mu.Unlock()
+	tick()
+	tick()
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+	if len(rotations) != 0 {
+		t.Errorf("rotated %v more times, want once", len(rotations))
+	}
+}
+
+func TestEngine_HandleNotification(t *testing.T) {
+	notificationTest := func(attributes map[string]string, wantRotated bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			f, c := newFakeSecret(t, "pw")
+			e := New(c)
+			_ = e.Register(gsm.SecretName{Secret: "db"}, 0, Rotator{Generate: func(ctx context.Context, current []byte) ([]byte, error) {
+				return []byte("new"), nil
+			}})
+
+			if err := e.HandleNotification(context.Background(), attributes); err != nil {
+				t.Fatalf("HandleNotification() error = %v", err)
+			}
+			want := "pw:ENABLED"
+			if wantRotated {
+				want = "pw:DISABLED new:ENABLED"
+			}
+			if got := f.states(); got != want {
+				t.Errorf("versions = %v, want %v", got, want)
+			}
+		}
+	}
+
+	t.Run("Rotate", notificationTest(map[string]string{"eventType": "SECRET_ROTATE", "secretId": "projects/myProject/secrets/db"}, true))
+	t.Run("OtherEvent", notificationTest(map[string]string{"eventType": "SECRET_VERSION_ADD", "secretId": "projects/myProject/secrets/db"}, false))
+	t.Run("OtherProject", notificationTest(map[string]string{"eventType": "SECRET_ROTATE", "secretId": "projects/otherProject/secrets/db"}, false))
+	t.Run("OtherSecret", notificationTest(map[string]string{"eventType": "SECRET_ROTATE", "secretId": "projects/myProject/secrets/api"}, false))
+}
@@ -0,0 +1,116 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestClient_UpdateSecretRotation(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+	next := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var got *pb.UpdateSecretRequest
+	UpdateSecretFunc = func(ctx context.Context, req *pb.UpdateSecretRequest) (*pb.Secret, error) {
+		got = req
+		return req.Secret, nil
+	}
+
+	_, err := c.UpdateSecret(context.Background(), SecretName{Secret: "mySecret"},
+		SecretTopics("projects/myProject/topics/rotations"),
+		SecretRotationPeriod(90*24*time.Hour),
+		SecretNextRotationTime(next),
+	)
+	if err != nil {
+		t.Fatalf("UpdateSecret() error = %v", err)
+	}
+	if want := []string{"topics", "rotation.rotation_period", "rotation.next_rotation_time"}; !reflect.DeepEqual(got.UpdateMask.Paths, want) {
+		t.Errorf("UpdateSecret() mask = %v, want %v", got.UpdateMask.Paths, want)
+	}
+	if period := got.Secret.Rotation.RotationPeriod.AsDuration(); period != 90*24*time.Hour {
+		t.Errorf("UpdateSecret() rotation period = %v", period)
+	}
+	if !got.Secret.Rotation.NextRotationTime.AsTime().Equal(next) {
+		t.Errorf("UpdateSecret() next rotation time = %v", got.Secret.Rotation.NextRotationTime)
+	}
+	if len(got.Secret.Topics) != 1 || got.Secret.Topics[0].Name != "projects/myProject/topics/rotations" {
+		t.Errorf("UpdateSecret() topics = %v", got.Secret.Topics)
+	}
+
+	invalidTest := func(opt SecretOption) func(t *testing.T) {
+		return func(t *testing.T) {
+			if _, err := c.UpdateSecret(context.Background(), SecretName{Secret: "mySecret"}, opt); !errors.Is(err, ErrInvalidOption) {
+				t.Errorf("UpdateSecret() error = %v, want %v", err, ErrInvalidOption)
+			}
+		}
+	}
+	t.Run("ShortPeriod", invalidTest(SecretRotationPeriod(time.Minute)))
+	t.Run("ZeroNextRotationTime", invalidTest(SecretNextRotationTime(time.Time{})))
+	t.Run("BadTopic", invalidTest(SecretTopics("rotations")))
+}
+
+func TestClient_SecretRotation(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+	next := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	GetSecretFunc = func(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+		if req.Name != "projects/myProject/secrets/mySecret" {
+			t.Errorf("GetSecret() name = %v", req.Name)
+		}
+		return &pb.Secret{
+			Rotation: &pb.Rotation{
+				RotationPeriod:   durationpb.New(90 * 24 * time.Hour),
+				NextRotationTime: timestamppb.New(next),
+			},
+			Topics: []*pb.Topic{{Name: "projects/myProject/topics/rotations"}},
+		}, nil
+	}
+
+	got, err := c.SecretRotation(context.Background(), SecretName{Secret: "mySecret"})
+	if err != nil {
+		t.Fatalf("SecretRotation() error = %v", err)
+	}
+	want := &Rotation{Period: 90 * 24 * time.Hour, NextRotationTime: next, Topics: []string{"projects/myProject/topics/rotations"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SecretRotation() = %v, want %v", got, want)
+	}
+
+	GetSecretFunc = func(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+		return &pb.Secret{}, nil
+	}
+	if got, err := c.SecretRotation(context.Background(), SecretName{Secret: "mySecret"}); err != nil || !reflect.DeepEqual(got, &Rotation{}) {
+		t.Errorf("SecretRotation() = %v, %v, want no rotation", got, err)
+	}
+}
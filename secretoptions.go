@@ -0,0 +1,89 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+)
+
+// SecretOption sets a field of a secret created with CreateSecret or changed with UpdateSecret
+type SecretOption func(*secretSpec)
+
+// secretSpec collects the fields set by SecretOptions and their update mask paths
+type secretSpec struct {
+	secret *pb.Secret
+	paths  []string
+	err    error
+}
+
+func newSecretSpec(opts []SecretOption) *secretSpec {
+	s := &secretSpec{secret: &pb.Secret{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// update records path in the update mask
+func (s *secretSpec) update(path string) {
+	for _, p := range s.paths {
+		if p == path {
+			return
+		}
+	}
+	s.paths = append(s.paths, path)
+}
+
+// fail records the first invalid option
+func (s *secretSpec) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// SecretLabels replaces the labels of the secret
+func SecretLabels(labels map[string]string) SecretOption {
+	return func(s *secretSpec) {
+		s.secret.Labels = labels
+		s.update("labels")
+	}
+}
+
+// SecretAnnotations replaces the annotations of the secret
+func SecretAnnotations(annotations map[string]string) SecretOption {
+	return func(s *secretSpec) {
+		s.secret.Annotations = annotations
+		s.update("annotations")
+	}
+}
+
+// SecretEtag makes UpdateSecret fail unless the secret is unchanged since etag was read
+func SecretEtag(etag string) SecretOption {
+	return func(s *secretSpec) {
+		s.secret.Etag = etag
+	}
+}
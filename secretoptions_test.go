@@ -0,0 +1,65 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+)
+
+func TestClient_CreateSecretOptions(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+
+	var got *pb.CreateSecretRequest
+	CreateSecretFunc = func(ctx context.Context, req *pb.CreateSecretRequest) (*pb.Secret, error) {
+		got = req
+		return req.Secret, nil
+	}
+
+	_, err := c.CreateSecret(context.Background(), SecretName{Secret: "mySecret"},
+		SecretLabels(map[string]string{"env": "prod"}),
+		SecretAnnotations(map[string]string{"owner": "payments"}),
+	)
+	if err != nil {
+		t.Fatalf("CreateSecret() error = %v", err)
+	}
+	if got.Parent != "projects/myProject" || got.SecretId != "mySecret" {
+		t.Errorf("CreateSecret() request = %v", got)
+	}
+	if !reflect.DeepEqual(got.Secret.Labels, map[string]string{"env": "prod"}) {
+		t.Errorf("CreateSecret() labels = %v", got.Secret.Labels)
+	}
+	if !reflect.DeepEqual(got.Secret.Annotations, map[string]string{"owner": "payments"}) {
+		t.Errorf("CreateSecret() annotations = %v", got.Secret.Annotations)
+	}
+	if got.Secret.GetReplication().GetAutomatic() == nil {
+		t.Errorf("CreateSecret() replication = %v, want automatic", got.Secret.Replication)
+	}
+}
@@ -28,11 +28,17 @@ package gsm
 
 import (
 	"context"
-	"fmt"
-	"log"
-	
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
 	sm "cloud.google.com/go/secretmanager/apiv1"
-	pb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/api/option"
 )
 
 // SecretClient to interface into the smc Client
@@ -43,202 +49,474 @@ type SecretClient interface {
 	AddSecretVersion(ctx context.Context, req *pb.AddSecretVersionRequest) (*pb.SecretVersion, error)
 	DeleteSecret(ctx context.Context, req *pb.DeleteSecretRequest) error
 	GetSecret(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error)
+	UpdateSecret(ctx context.Context, req *pb.UpdateSecretRequest) (*pb.Secret, error)
 	GetSecretVersion(ctx context.Context, req *pb.GetSecretVersionRequest) (*pb.SecretVersion, error)
 	DisableSecretVersion(ctx context.Context, req *pb.DisableSecretVersionRequest) (*pb.SecretVersion, error)
 	EnableSecretVersion(ctx context.Context, req *pb.EnableSecretVersionRequest) (*pb.SecretVersion, error)
+	ListSecrets(ctx context.Context, req *pb.ListSecretsRequest) (*pb.ListSecretsResponse, error)
+	ListSecretVersions(ctx context.Context, req *pb.ListSecretVersionsRequest) (*pb.ListSecretVersionsResponse, error)
 	Close() error
 }
 
 // Client is a global exported Client struct
 type Client struct {
-	smc SecretClient
+	smc           SecretClient
+	projectID     string
+	projectMu     sync.Mutex
+	projectErr    error
+	projectErrAt  time.Time
+	clientOpts    []option.ClientOption
+	logger        *slog.Logger
+	cache         *cache
+	accesses      flightGroup[*pb.AccessSecretVersionResponse]
+	noChecksums   bool
+	retry         *RetryPolicy
+	timeouts      Timeouts
+	accessLimiter Limiter
+	adminLimiter  Limiter
+	useBreaker    bool
+	breakerOpts   []BreakerOption
+	breaker       *CircuitBreaker
+	tracer        trace.Tracer
+	meterProvider metric.MeterProvider
+	registerer    prometheus.Registerer
+	metrics       []metricsRecorder
+	middleware    []Middleware
 }
 
-// NewClient is a global exported function that creates a new client
-func NewClient(ctx context.Context) (*sm.Client, error) {
-	client, err := sm.NewClient(ctx)
-	if err != nil {
+// NewClient is a global exported function that creates a new client.
+// Unless WithSecretClient is given, a Secret Manager client is dialed with the options passed to WithClientOptions
+func NewClient(ctx context.Context, opts ...Option) (*Client, error) {
+	c := &Client{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := c.initMetrics(); err != nil {
 		return nil, err
 	}
-	return client, nil
+
+	if c.smc == nil {
+		client, err := sm.NewClient(ctx, c.clientOpts...)
+		if err != nil {
+			return nil, err
+		}
+		c.smc = &grpcClient{c: client}
+	}
+	if c.useBreaker {
+		c.breaker = NewCircuitBreaker(c.smc, c.breakerOpts...)
+		c.smc = c.breaker
+	}
+	if len(c.middleware) > 0 {
+		c.smc = Chain(c.middleware...)(c.smc)
+	}
+	return c, nil
+}
+
+// Close closes the underlying SecretClient
+func (c *Client) Close() error {
+	return c.smc.Close()
+}
+
+// call runs fn, which makes the SecretClient call op on secret, logging the outcome and wrapping any error.
+// Reads are retried according to the retry policy, and the call is limited by the default timeout of op.
+// Every attempt waits for the rate limiter of op
+func (c *Client) call(ctx context.Context, op string, secret SecretName, version string, fn func(ctx context.Context) error) (err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, span := c.startSpan(ctx, op, secret, version)
+	defer func() { endSpan(span, err) }()
+
+	callCtx, cancel, timeout := c.withTimeout(ctx, op)
+	defer cancel()
+
+	maxAttempts := c.retry.attempts(op)
+	for attempt := 1; ; attempt++ {
+		if limiter := c.limiter(op); limiter != nil {
+			if err := limiter.Wait(callCtx); err != nil {
+				return c.callError(ctx, callCtx, op, secret, version, err, attempt, timeout)
+			}
+		}
+
+		start := time.Now()
+		err := fn(callCtx)
+		latency := time.Since(start)
+		c.logCall(callCtx, op, secret, version, latency, err)
+		c.recordRequest(callCtx, op, latency, err)
+		if err == nil {
+			return nil
+		}
+
+		if attempt < maxAttempts && c.retry.retryable(err) && wait(callCtx, c.retry.backoff(attempt)) {
+			c.recordRetry(callCtx, op)
+			continue
+		}
+		return c.callError(ctx, callCtx, op, secret, version, err, attempt, timeout)
+	}
+}
+
+// callError wraps the error of the last attempt of a call, marking it as a timeout when callCtx ran out
+// of the default timeout while ctx, the context of the caller, did not
+func (c *Client) callError(ctx context.Context, callCtx context.Context, op string, secret SecretName, version string, err error, attempts int, timeout time.Duration) error {
+	err = wrapError(op, resourceName(secret, version), err)
+	var secretErr *SecretError
+	if errors.As(err, &secretErr) {
+		secretErr.Attempts = attempts
+	}
+	if timeout > 0 && callCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		err = timeoutError(err, op, timeout)
+	}
+	return err
 }
 
 // CreateEmptySecret function
 func (c *Client) CreateEmptySecret(ctx context.Context, secretName string, projectId string) (*pb.Secret, error) {
-	createSecretReq := pb.CreateSecretRequest{
-		Parent:   fmt.Sprintf("projects/%s", projectId),
-		SecretId: secretName,
-		Secret: &pb.Secret{
-			Replication: &pb.Replication{
-				Replication: &pb.Replication_Automatic_{
-					Automatic: &pb.Replication_Automatic{},
-				},
-			},
-		},
+	return c.CreateSecret(ctx, SecretName{Project: projectId, Secret: secretName})
+}
+
+// CreateSecretWithData creates secret with data. opts set other fields of the secret, such as SecretTTL
+func (c *Client) CreateSecretWithData(ctx context.Context, secretName string, payload []byte, projectId string, opts ...SecretOption) (*pb.SecretVersion, error) {
+	name, err := c.secretName(ctx, SecretName{Project: projectId, Secret: secretName})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.CreateSecret(ctx, name, opts...); err != nil {
+		return nil, err
 	}
-	
-	secret, err := c.smc.CreateSecret(ctx, &createSecretReq)
+
+	return c.AddSecretVersion(ctx, name, payload)
+}
+
+// SecretExists Checks if secret exists. Any error, not just NotFound, is reported as false; see CheckSecretExists
+func (c *Client) SecretExists(ctx context.Context, secretName string, projectId string) bool {
+	exists, err := c.CheckSecretExists(ctx, SecretName{Project: projectId, Secret: secretName})
+	return exists && err == nil
+}
+
+// AddNewSecretVersion Adds a new Version of a secret on a secret name
+func (c *Client) AddNewSecretVersion(ctx context.Context, secretName string, projectId string, payload []byte) (*pb.SecretVersion, error) {
+	return c.AddSecretVersion(ctx, SecretName{Project: projectId, Secret: secretName}, payload)
+}
+
+// GetSecret Gets secret data
+func (c *Client) GetSecret(ctx context.Context, secretName string, projectId string, version string) (*pb.SecretPayload, error) {
+	result, err := c.AccessSecretVersion(ctx, SecretName{Project: projectId, Secret: secretName}.Version(version))
 	if err != nil {
-		log.Printf("failed to create secret: %v", err)
 		return nil, err
 	}
-	
-	return secret, nil
+
+	return result.Payload, nil
 }
 
-// CreateSecretWithData creates secret with data
-func (c *Client) CreateSecretWithData(ctx context.Context, secretName string, payload []byte, projectId string) (*pb.SecretVersion, error) {
-	createSecretReq := pb.CreateSecretRequest{
-		Parent:   fmt.Sprintf("projects/%s", projectId),
-		SecretId: secretName,
-		Secret: &pb.Secret{
-			Replication: &pb.Replication{
-				Replication: &pb.Replication_Automatic_{
-					Automatic: &pb.Replication_Automatic{},
-				},
+// DeleteSecretAndVersions Deletes secret with all the versions included
+func (c *Client) DeleteSecretAndVersions(ctx context.Context, secretName string, projectId string) error {
+	return c.DeleteSecret(ctx, SecretName{Project: projectId, Secret: secretName})
+}
+
+// DeleteSecretVersion Deletes specific version of a secret
+func (c *Client) DeleteSecretVersion(ctx context.Context, secretName string, projectId string, version string) (*pb.SecretVersion, error) {
+	return c.DestroySecretVersion(ctx, SecretName{Project: projectId, Secret: secretName}.Version(version))
+}
+
+// GetSecretMetadata Gets metadata of a secret Name
+func (c *Client) GetSecretMetadata(ctx context.Context, secretName string, projectId string, version string) (*pb.SecretVersion, error) {
+	return c.GetSecretVersion(ctx, SecretName{Project: projectId, Secret: secretName}.Version(version))
+}
+
+// DisableSecret Disables secret
+func (c *Client) DisableSecret(ctx context.Context, secretName string, projectId string, version string) (*pb.SecretVersion, error) {
+	return c.DisableSecretVersion(ctx, SecretName{Project: projectId, Secret: secretName}.Version(version))
+}
+
+// EnableSecret Enables secret
+func (c *Client) EnableSecret(ctx context.Context, secretName string, projectId string, version string) (*pb.SecretVersion, error) {
+	return c.EnableSecretVersion(ctx, SecretName{Project: projectId, Secret: secretName}.Version(version))
+}
+
+// CreateSecret creates a secret without any versions, with the fields set by opts
+func (c *Client) CreateSecret(ctx context.Context, name SecretName, opts ...SecretOption) (*pb.Secret, error) {
+	name, err := c.secretName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := newSecretSpec(opts)
+	if spec.err != nil {
+		return nil, spec.err
+	}
+	if spec.secret.Replication == nil {
+		spec.secret.Replication = &pb.Replication{
+			Replication: &pb.Replication_Automatic_{
+				Automatic: &pb.Replication_Automatic{},
 			},
-		},
+		}
 	}
-	
-	secret, err := c.smc.CreateSecret(ctx, &createSecretReq)
+
+	createSecretReq := pb.CreateSecretRequest{
+		Parent:   name.Parent(),
+		SecretId: name.Secret,
+		Secret:   spec.secret,
+	}
+
+	var secret *pb.Secret
+	err = c.call(ctx, "CreateSecret", name, "", func(ctx context.Context) (err error) {
+		secret, err = c.smc.CreateSecret(ctx, &createSecretReq)
+		return err
+	})
 	if err != nil {
-		log.Printf("failed to create secret: %v\n", err)
 		return nil, err
 	}
-	
-	addSecretVersionReq := pb.AddSecretVersionRequest{
-		Parent: secret.Name,
-		Payload: &pb.SecretPayload{
-			Data: payload,
-		},
+
+	return secret, nil
+}
+
+// LookupSecret gets the metadata of a secret
+func (c *Client) LookupSecret(ctx context.Context, name SecretName) (*pb.Secret, error) {
+	name, err := c.secretName(ctx, name)
+	if err != nil {
+		return nil, err
 	}
-	
-	version, err := c.smc.AddSecretVersion(ctx, &addSecretVersionReq)
+
+	getSecretReq := pb.GetSecretRequest{
+		Name: name.String(),
+	}
+
+	var secret *pb.Secret
+	err = c.call(ctx, "GetSecret", name, "", func(ctx context.Context) (err error) {
+		secret, err = c.smc.GetSecret(ctx, &getSecretReq)
+		return err
+	})
 	if err != nil {
-		log.Printf("failed to add secret version: %v\n", err)
 		return nil, err
 	}
-	
-	return version, err
+
+	return secret, nil
 }
 
-// SecretExists Checks if secret exists
-func (c *Client) SecretExists(ctx context.Context, secretName string, projectId string) bool {
-	accessRequest := pb.GetSecretRequest{
-		Name: fmt.Sprintf("projects/%v/secrets/%v", projectId, secretName)}
-	
-	_, err := c.smc.GetSecret(ctx, &accessRequest)
+// CheckSecretExists checks if a secret exists. It returns false without an error only when the secret is not found,
+// every other failure is returned
+func (c *Client) CheckSecretExists(ctx context.Context, name SecretName) (bool, error) {
+	_, err := c.LookupSecret(ctx, name)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
 	if err != nil {
-		return false
+		return false, err
 	}
-	return true
+	return true, nil
 }
 
-// AddNewSecretVersion Adds a new Version of a secret on a secret name
-func (c *Client) AddNewSecretVersion(ctx context.Context, secretName string, projectId string, payload []byte) (*pb.SecretVersion, error) {
+// VersionExists checks if a secret version exists and returns its state. It returns false without an error only
+// when the version is not found, every other failure is returned
+func (c *Client) VersionExists(ctx context.Context, name VersionName) (bool, pb.SecretVersion_State, error) {
+	version, err := c.GetSecretVersion(ctx, name)
+	if errors.Is(err, ErrNotFound) {
+		return false, pb.SecretVersion_STATE_UNSPECIFIED, nil
+	}
+	if err != nil {
+		return false, pb.SecretVersion_STATE_UNSPECIFIED, err
+	}
+	return true, version.State, nil
+}
+
+// AddSecretVersion adds a new version holding payload to a secret
+func (c *Client) AddSecretVersion(ctx context.Context, name SecretName, payload []byte) (*pb.SecretVersion, error) {
+	name, err := c.secretName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
 	addSecretVersionReq := pb.AddSecretVersionRequest{
-		Parent: fmt.Sprintf("projects/%v/secrets/%v", projectId, secretName),
+		Parent: name.String(),
 		Payload: &pb.SecretPayload{
 			Data: payload,
 		},
 	}
-	version, err := c.smc.AddSecretVersion(ctx, &addSecretVersionReq)
+	c.setChecksum(addSecretVersionReq.Payload)
+
+	var version *pb.SecretVersion
+	err = c.call(ctx, "AddSecretVersion", name, "", func(ctx context.Context) (err error) {
+		version, err = c.smc.AddSecretVersion(ctx, &addSecretVersionReq)
+		return err
+	})
 	if err != nil {
-		log.Printf("failed to add secret version: %v", err)
 		return nil, err
 	}
-	
+
+	c.cache.invalidate(name)
 	return version, nil
 }
 
-// GetSecret Gets secret data
-func (c *Client) GetSecret(ctx context.Context, secretName string, projectId string, version string) (*pb.SecretPayload, error) {
-	if version == "" {
-		version = "latest"
+// AccessSecretVersion gets the data of a secret version. The name of the result holds the resolved version number
+func (c *Client) AccessSecretVersion(ctx context.Context, name VersionName) (*pb.AccessSecretVersionResponse, error) {
+	name, err := c.versionName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, state, staleness := c.cache.get(name)
+	if c.cache != nil {
+		c.recordCacheLookup(ctx, state == cacheFresh || state == cacheRefresh)
 	}
-	
-	getSecret := pb.AccessSecretVersionRequest{
-		Name: fmt.Sprintf("projects/%v/secrets/%v/versions/%v", projectId, secretName, version),
+	switch state {
+	case cacheFresh:
+		return cached, nil
+	case cacheRefresh:
+		go c.refresh(ctx, name)
+		return cached, nil
 	}
-	
-	result, err := c.smc.AccessSecretVersion(ctx, &getSecret)
+
+	result, err := c.accessSecretVersion(ctx, name)
+	if err != nil && state == cacheStale && servesStale(err) {
+		c.cache.stale(name, staleness, err)
+		return cached, nil
+	}
+	return result, err
+}
+
+// accessSecretVersion reads a version from Secret Manager and caches it. Concurrent reads of the same version share one call
+func (c *Client) accessSecretVersion(ctx context.Context, name VersionName) (*pb.AccessSecretVersionResponse, error) {
+	accessSecretReq := pb.AccessSecretVersionRequest{
+		Name: name.String(),
+	}
+
+	result, err := c.accesses.do(ctx, accessSecretReq.Name, func(ctx context.Context) (*pb.AccessSecretVersionResponse, error) {
+		var result *pb.AccessSecretVersionResponse
+		err := c.call(ctx, "AccessSecretVersion", name.SecretName, name.version(), func(ctx context.Context) (err error) {
+			result, err = c.smc.AccessSecretVersion(ctx, &accessSecretReq)
+			if err != nil {
+				return err
+			}
+			return c.verifyChecksum("AccessSecretVersion", name, result.GetPayload())
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		c.cache.put(name, result)
+		return result, nil
+	})
 	if err != nil {
-		log.Printf("failed to get secret: %v", err)
-		return nil, err
+		// a caller that gave up before the shared call ended gets its context error
+		return nil, wrapError("AccessSecretVersion", accessSecretReq.Name, err)
 	}
-	
-	return result.Payload, nil
+	return result, nil
 }
 
-// DeleteSecretAndVersions Deletes secret with all the versions included
-func (c *Client) DeleteSecretAndVersions(ctx context.Context, secretName string, projectId string) error {
+// DeleteSecret deletes a secret with all its versions
+func (c *Client) DeleteSecret(ctx context.Context, name SecretName) error {
+	name, err := c.secretName(ctx, name)
+	if err != nil {
+		return err
+	}
+
 	deleteSecretReq := pb.DeleteSecretRequest{
-		Name: fmt.Sprintf("projects/%v/secrets/%v", projectId, secretName),
+		Name: name.String(),
 	}
-	
-	err := c.smc.DeleteSecret(ctx, &deleteSecretReq)
-	if err == nil {
-		log.Printf("Secret Deleted Successfully")
+
+	err = c.call(ctx, "DeleteSecret", name, "", func(ctx context.Context) error {
+		return c.smc.DeleteSecret(ctx, &deleteSecretReq)
+	})
+	if err != nil {
+		return err
 	}
-	
-	return err
+
+	c.cache.invalidate(name)
+	return nil
 }
 
-// DeleteSecretVersion Deletes specific version of a secret
-func (c *Client) DeleteSecretVersion(ctx context.Context, secretName string, projectId string, version string) (*pb.SecretVersion, error) {
-	
+// DestroySecretVersion destroys the data of a secret version
+func (c *Client) DestroySecretVersion(ctx context.Context, name VersionName) (*pb.SecretVersion, error) {
+	name, err := c.changedVersionName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
 	destroySecretReq := pb.DestroySecretVersionRequest{
-		Name: fmt.Sprintf("projects/%v/secrets/%v/versions/%v", projectId, secretName, version),
+		Name: name.String(),
 	}
-	result, err := c.smc.DestroySecretVersion(ctx, &destroySecretReq)
+
+	var result *pb.SecretVersion
+	err = c.call(ctx, "DestroySecretVersion", name.SecretName, name.version(), func(ctx context.Context) (err error) {
+		result, err = c.smc.DestroySecretVersion(ctx, &destroySecretReq)
+		return err
+	})
 	if err != nil {
-		log.Printf("failed to get secret: %v", err)
 		return nil, err
 	}
+
+	c.cache.invalidate(name.SecretName)
 	return result, nil
 }
 
-// GetSecretMetadata Gets metadata of a secret Name
-func (c *Client) GetSecretMetadata(ctx context.Context, secretName string, projectId string, version string) (*pb.SecretVersion, error) {
+// GetSecretVersion gets the metadata of a secret version
+func (c *Client) GetSecretVersion(ctx context.Context, name VersionName) (*pb.SecretVersion, error) {
+	name, err := c.versionName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
 	getSecretReq := pb.GetSecretVersionRequest{
-		Name: fmt.Sprintf("projects/%v/secrets/%v/versions/%v", projectId, secretName, version),
+		Name: name.String(),
 	}
-	
-	result, err := c.smc.GetSecretVersion(ctx, &getSecretReq)
+
+	var result *pb.SecretVersion
+	err = c.call(ctx, "GetSecretVersion", name.SecretName, name.version(), func(ctx context.Context) (err error) {
+		result, err = c.smc.GetSecretVersion(ctx, &getSecretReq)
+		return err
+	})
 	if err != nil {
-		log.Printf("failed to get secret: %v", err)
 		return nil, err
 	}
-	
+
 	return result, nil
 }
 
-// DisableSecret Disables secret
-func (c *Client) DisableSecret(ctx context.Context, secretName string, projectId string, version string) (*pb.SecretVersion, error) {
+// DisableSecretVersion disables a secret version
+func (c *Client) DisableSecretVersion(ctx context.Context, name VersionName) (*pb.SecretVersion, error) {
+	name, err := c.changedVersionName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
 	disableSecretReq := pb.DisableSecretVersionRequest{
-		Name: fmt.Sprintf("projects/%v/secrets/%v/versions/%v", projectId, secretName, version),
+		Name: name.String(),
 	}
-	
-	result, err := c.smc.DisableSecretVersion(ctx, &disableSecretReq)
+
+	var result *pb.SecretVersion
+	err = c.call(ctx, "DisableSecretVersion", name.SecretName, name.version(), func(ctx context.Context) (err error) {
+		result, err = c.smc.DisableSecretVersion(ctx, &disableSecretReq)
+		return err
+	})
 	if err != nil {
-		log.Printf("failed to get secret: %v", err)
 		return nil, err
 	}
-	
+
+	c.cache.invalidate(name.SecretName)
 	return result, nil
 }
 
-// EnableSecret Enables secret
-func (c *Client) EnableSecret(ctx context.Context, secretName string, projectId string, version string) (*pb.SecretVersion, error) {
+// EnableSecretVersion enables a secret version
+func (c *Client) EnableSecretVersion(ctx context.Context, name VersionName) (*pb.SecretVersion, error) {
+	name, err := c.changedVersionName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
 	enableSecretReq := pb.EnableSecretVersionRequest{
-		Name: fmt.Sprintf("projects/%v/secrets/%v/versions/%v", projectId, secretName, version),
+		Name: name.String(),
 	}
-	
-	result, err := c.smc.EnableSecretVersion(ctx, &enableSecretReq)
+
+	var result *pb.SecretVersion
+	err = c.call(ctx, "EnableSecretVersion", name.SecretName, name.version(), func(ctx context.Context) (err error) {
+		result, err = c.smc.EnableSecretVersion(ctx, &enableSecretReq)
+		return err
+	})
 	if err != nil {
-		log.Printf("failed to get secret: %v\n", err)
 		return nil, err
 	}
-	
+
+	c.cache.invalidate(name.SecretName)
 	return result, nil
 }
@@ -32,7 +32,9 @@ import (
 	"reflect"
 	"testing"
 
-	pb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var client = &MockClient{}
@@ -178,7 +180,8 @@ func TestClient_DeleteSecretVersion(t *testing.T) {
 	DestroySecretVersionFunc = func(ctx context.Context, req *pb.DestroySecretVersionRequest) (*pb.SecretVersion, error) {
 		return secretVersionPositiveReturn, nil
 	}
-	t.Run("Success", deleteSecretVersionTest(nil, "mySecrets", "myProjects", "", secretVersionPositiveReturn, false))
+	t.Run("Success", deleteSecretVersionTest(nil, "mySecrets", "myProjects", "1", secretVersionPositiveReturn, false))
+	t.Run("EmptyVersion", deleteSecretVersionTest(nil, "mySecrets", "myProjects", "", nil, true))
 
 	DestroySecretVersionFunc = func(ctx context.Context, req *pb.DestroySecretVersionRequest) (*pb.SecretVersion, error) {
 		return nil, errors.New("failed to delete secret version")
@@ -220,7 +223,8 @@ func TestClient_EnableSecret(t *testing.T) {
 	enableSecretTest := func(ctx context.Context, secretName string, projectId string, version string, want *pb.SecretVersion, wantErr bool) func(t *testing.T) {
 		return func(t *testing.T) {
 			c := &Client{
-				smc: client,
+				smc:       client,
+				projectID: "myProjects",
 			}
 			got, err := c.EnableSecret(ctx, secretName, projectId, version)
 			if (err != nil) != wantErr {
@@ -236,7 +240,8 @@ func TestClient_EnableSecret(t *testing.T) {
 	EnableSecretVersionFunc = func(ctx context.Context, req *pb.EnableSecretVersionRequest) (*pb.SecretVersion, error) {
 		return secretVersionPositiveReturn, nil
 	}
-	t.Run("Success", enableSecretTest(nil, "", "", "", secretVersionPositiveReturn, false))
+	t.Run("Success", enableSecretTest(nil, "mySecrets", "", "1", secretVersionPositiveReturn, false))
+	t.Run("EmptyVersion", enableSecretTest(nil, "mySecrets", "", "", nil, true))
 	t.Run("Failure", enableSecretTest(nil, "mySecrets", "myProjects", "1", secretVersionPositiveReturn, false))
 
 	EnableSecretVersionFunc = func(ctx context.Context, req *pb.EnableSecretVersionRequest) (*pb.SecretVersion, error) {
@@ -354,3 +359,74 @@ func TestClient_SecretExists(t *testing.T) {
 	}
 	t.Run("Failure", secretExists(nil, "mysecret", "my-project", false))
 }
+
+func TestClient_CheckSecretExists(t *testing.T) {
+	checkSecretExists := func(ctx context.Context, name SecretName, want bool, wantErr bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			c := &Client{
+				smc: client,
+			}
+			got, err := c.CheckSecretExists(ctx, name)
+			if (err != nil) != wantErr {
+				t.Errorf("CheckSecretExists() error = %v, wantErr %v", err, wantErr)
+				return
+			}
+			if got != want {
+				t.Errorf("CheckSecretExists() got = %v, want %v", got, want)
+			}
+		}
+	}
+
+	GetSecretFunc = func(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+		return secretPositiveReturn, nil
+	}
+	t.Run("Exists", checkSecretExists(nil, SecretName{Project: "myProject", Secret: "mySecret"}, true, false))
+
+	GetSecretFunc = func(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+		return nil, status.Error(codes.NotFound, "Secret not found")
+	}
+	t.Run("NotFound", checkSecretExists(nil, SecretName{Project: "myProject", Secret: "mySecret"}, false, false))
+
+	GetSecretFunc = func(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	}
+	t.Run("PermissionDenied", checkSecretExists(nil, SecretName{Project: "myProject", Secret: "mySecret"}, false, true))
+
+	GetSecretFunc = func(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+		return nil, status.Error(codes.DeadlineExceeded, "Deadline exceeded")
+	}
+	t.Run("Timeout", checkSecretExists(nil, SecretName{Project: "myProject", Secret: "mySecret"}, false, true))
+}
+
+func TestClient_VersionExists(t *testing.T) {
+	versionExists := func(ctx context.Context, name VersionName, want bool, wantState pb.SecretVersion_State, wantErr bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			c := &Client{
+				smc: client,
+			}
+			got, state, err := c.VersionExists(ctx, name)
+			if (err != nil) != wantErr {
+				t.Errorf("VersionExists() error = %v, wantErr %v", err, wantErr)
+				return
+			}
+			if got != want || state != wantState {
+				t.Errorf("VersionExists() got = %v, %v, want %v, %v", got, state, want, wantState)
+			}
+		}
+	}
+
+	GetSecretVersionFunc = func(ctx context.Context, req *pb.GetSecretVersionRequest) (*pb.SecretVersion, error) {
+		return &pb.SecretVersion{Name: req.Name, State: pb.SecretVersion_DISABLED}, nil
+	}
+	t.Run("Disabled", versionExists(nil, SecretName{Project: "myProject", Secret: "mySecret"}.Version("2"), true, pb.SecretVersion_DISABLED, false))
+
+	GetSecretVersionFunc = func(ctx context.Context, req *pb.GetSecretVersionRequest) (*pb.SecretVersion, error) {
+		return nil, status.Error(codes.NotFound, "Secret Version not found")
+	}
+	t.Run("NotFound", versionExists(nil, SecretName{Project: "myProject", Secret: "mySecret"}.Version("2"), false, pb.SecretVersion_STATE_UNSPECIFIED, false))
+
+	GetSecretVersionFunc = func(ctx context.Context, req *pb.GetSecretVersionRequest) (*pb.SecretVersion, error) {
+		return nil, status.Error(codes.Unavailable, "Service unavailable")
+	}
+	t.Run("Unavailable", versionExists(nil, SecretName{Project: "myProject", Secret: "mySecret"}.Version("2"), false, pb.SecretVersion_STATE_UNSPECIFIED, true))
+}
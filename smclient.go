@@ -0,0 +1,126 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+
+	sm "cloud.google.com/go/secretmanager/apiv1"
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/api/iterator"
+)
+
+// maxPageSize is the most resources Secret Manager returns in one page
+const maxPageSize = 25000
+
+// grpcClient adapts the Secret Manager gRPC client to the SecretClient interface
+type grpcClient struct {
+	c *sm.Client
+}
+
+// AccessSecretVersion Access SecretVersion
+func (g *grpcClient) AccessSecretVersion(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+	return g.c.AccessSecretVersion(ctx, req)
+}
+
+// DestroySecretVersion Destroy Secret Version
+func (g *grpcClient) DestroySecretVersion(ctx context.Context, req *pb.DestroySecretVersionRequest) (*pb.SecretVersion, error) {
+	return g.c.DestroySecretVersion(ctx, req)
+}
+
+// CreateSecret Create Secret
+func (g *grpcClient) CreateSecret(ctx context.Context, req *pb.CreateSecretRequest) (*pb.Secret, error) {
+	return g.c.CreateSecret(ctx, req)
+}
+
+// AddSecretVersion Add Secret Version
+func (g *grpcClient) AddSecretVersion(ctx context.Context, req *pb.AddSecretVersionRequest) (*pb.SecretVersion, error) {
+	return g.c.AddSecretVersion(ctx, req)
+}
+
+// DeleteSecret Delete Secret
+func (g *grpcClient) DeleteSecret(ctx context.Context, req *pb.DeleteSecretRequest) error {
+	return g.c.DeleteSecret(ctx, req)
+}
+
+// GetSecret Get Secret
+func (g *grpcClient) GetSecret(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+	return g.c.GetSecret(ctx, req)
+}
+
+// UpdateSecret Update Secret
+func (g *grpcClient) UpdateSecret(ctx context.Context, req *pb.UpdateSecretRequest) (*pb.Secret, error) {
+	return g.c.UpdateSecret(ctx, req)
+}
+
+// GetSecretVersion Get Secret Version
+func (g *grpcClient) GetSecretVersion(ctx context.Context, req *pb.GetSecretVersionRequest) (*pb.SecretVersion, error) {
+	return g.c.GetSecretVersion(ctx, req)
+}
+
+// DisableSecretVersion Disable Secret Version
+func (g *grpcClient) DisableSecretVersion(ctx context.Context, req *pb.DisableSecretVersionRequest) (*pb.SecretVersion, error) {
+	return g.c.DisableSecretVersion(ctx, req)
+}
+
+// EnableSecretVersion Enable Secret Version
+func (g *grpcClient) EnableSecretVersion(ctx context.Context, req *pb.EnableSecretVersionRequest) (*pb.SecretVersion, error) {
+	return g.c.EnableSecretVersion(ctx, req)
+}
+
+// ListSecrets List Secrets, one page per call
+func (g *grpcClient) ListSecrets(ctx context.Context, req *pb.ListSecretsRequest) (*pb.ListSecretsResponse, error) {
+	var secrets []*pb.Secret
+	token, err := iterator.NewPager(g.c.ListSecrets(ctx, req), pageSize(req.PageSize), req.PageToken).NextPage(&secrets)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListSecretsResponse{Secrets: secrets, NextPageToken: token}, nil
+}
+
+// ListSecretVersions List Secret Versions, one page per call
+func (g *grpcClient) ListSecretVersions(ctx context.Context, req *pb.ListSecretVersionsRequest) (*pb.ListSecretVersionsResponse, error) {
+	var versions []*pb.SecretVersion
+	token, err := iterator.NewPager(g.c.ListSecretVersions(ctx, req), pageSize(req.PageSize), req.PageToken).NextPage(&versions)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListSecretVersionsResponse{Versions: versions, NextPageToken: token}, nil
+}
+
+// pageSize returns the size of a requested page, the largest page Secret Manager returns when none is set
+func pageSize(requested int32) int {
+	if requested <= 0 {
+		return maxPageSize
+	}
+	return int(requested)
+}
+
+// Close Close Client
+func (g *grpcClient) Close() error {
+	return g.c.Close()
+}
@@ -0,0 +1,137 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	sm "cloud.google.com/go/secretmanager/apiv1"
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// pagingServer serves secrets and versions in pages of at most pageSize
+type pagingServer struct {
+	pb.UnimplementedSecretManagerServiceServer
+	items    int
+	pageSize int
+}
+
+// page returns the names in the page starting at token and the token of the next page
+func (s *pagingServer) page(kind string, token string, pageSize int32) ([]string, string) {
+	start := 0
+	fmt.Sscan(token, &start)
+	size := s.pageSize
+	if pageSize > 0 && int(pageSize) < size {
+		size = int(pageSize)
+	}
+	var names []string
+	for i := start; i < s.items && len(names) < size; i++ {
+		names = append(names, fmt.Sprintf("projects/p/%v/%v", kind, i))
+	}
+	if next := start + len(names); next < s.items {
+		return names, fmt.Sprint(next)
+	}
+	return names, ""
+}
+
+func (s *pagingServer) ListSecrets(ctx context.Context, req *pb.ListSecretsRequest) (*pb.ListSecretsResponse, error) {
+	names, token := s.page("secrets", req.PageToken, req.PageSize)
+	resp := &pb.ListSecretsResponse{NextPageToken: token}
+	for _, name := range names {
+		resp.Secrets = append(resp.Secrets, &pb.Secret{Name: name})
+	}
+	return resp, nil
+}
+
+func (s *pagingServer) ListSecretVersions(ctx context.Context, req *pb.ListSecretVersionsRequest) (*pb.ListSecretVersionsResponse, error) {
+	names, token := s.page("versions", req.PageToken, req.PageSize)
+	resp := &pb.ListSecretVersionsResponse{NextPageToken: token}
+	for _, name := range names {
+		resp.Versions = append(resp.Versions, &pb.SecretVersion{Name: name})
+	}
+	return resp, nil
+}
+
+func newPagingClient(t *testing.T, srv *pagingServer) *grpcClient {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	pb.RegisterSecretManagerServiceServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error = %v", err)
+	}
+	c, err := sm.NewClient(context.Background(), option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatalf("sm.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return &grpcClient{c: c}
+}
+
+func TestGrpcClient_ListPages(t *testing.T) {
+	g := newPagingClient(t, &pagingServer{items: 5, pageSize: 100})
+
+	var tokens []string
+	var got int
+	token := ""
+	for {
+		resp, err := g.ListSecrets(context.Background(), &pb.ListSecretsRequest{Parent: "projects/p", PageSize: 2, PageToken: token})
+		if err != nil {
+			t.Fatalf("ListSecrets() error = %v", err)
+		}
+		got += len(resp.Secrets)
+		token = resp.NextPageToken
+		tokens = append(tokens, token)
+		if token == "" {
+			break
+		}
+	}
+	if got != 5 || fmt.Sprint(tokens) != "[2 4 ]" {
+		t.Errorf("ListSecrets() listed %v secrets with tokens %q, want 5 with [2 4 ]", got, tokens)
+	}
+
+	resp, err := g.ListSecretVersions(context.Background(), &pb.ListSecretVersionsRequest{Parent: "projects/p/secrets/s"})
+	if err != nil {
+		t.Fatalf("ListSecretVersions() error = %v", err)
+	}
+	if len(resp.Versions) != 5 || resp.NextPageToken != "" {
+		t.Errorf("ListSecretVersions() listed %v versions with token %q, want 5 and no token", len(resp.Versions), resp.NextPageToken)
+	}
+}
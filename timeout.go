@@ -0,0 +1,96 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Timeouts are the default time limits of calls to Secret Manager by class of operation.
+// A zero timeout leaves calls of that class unlimited
+type Timeouts struct {
+	// Read limits AccessSecretVersion, GetSecret, GetSecretVersion, ListSecrets and ListSecretVersions
+	Read time.Duration
+	// Write limits AddSecretVersion
+	Write time.Duration
+	// Admin limits CreateSecret, UpdateSecret, DeleteSecret and the calls that change the state of versions
+	Admin time.Duration
+}
+
+// WithTimeouts limits how long calls may take, including retries. A timeout only applies when the context
+// of the call has no earlier deadline. Calls stopped by it fail with an error matching ErrTimeout
+func WithTimeouts(timeouts Timeouts) Option {
+	return func(c *Client) {
+		c.timeouts = timeouts
+	}
+}
+
+// writeOps are the SecretClient calls that add data; other calls that are not reads are admin calls
+var writeOps = map[string]bool{
+	"AddSecretVersion": true,
+}
+
+// timeout returns the default timeout of op
+func (t Timeouts) timeout(op string) time.Duration {
+	switch {
+	case idempotentOps[op]:
+		return t.Read
+	case writeOps[op]:
+		return t.Write
+	}
+	return t.Admin
+}
+
+// withTimeout applies the default timeout of op to ctx unless ctx has an earlier deadline.
+// It returns the timeout applied, or 0
+func (c *Client) withTimeout(ctx context.Context, op string) (context.Context, context.CancelFunc, time.Duration) {
+	timeout := c.timeouts.timeout(op)
+	if timeout <= 0 {
+		return ctx, func() {}, 0
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		return ctx, func() {}, 0
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, timeout
+}
+
+// timeoutError marks err as caused by the default timeout of op
+func timeoutError(err error, op string, timeout time.Duration) error {
+	var secretErr *SecretError
+	if !errors.As(err, &secretErr) {
+		return err
+	}
+	secretErr.Code = codes.DeadlineExceeded
+	secretErr.Err = fmt.Errorf("%w: %v did not complete within %v: %w", ErrTimeout, op, timeout, secretErr.Err)
+	return err
+}
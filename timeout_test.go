@@ -0,0 +1,88 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+)
+
+func TestClient_Timeouts(t *testing.T) {
+	timeoutTest := func(ctxTimeout time.Duration, timeouts Timeouts, wantTimeout bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			c := &Client{smc: client, projectID: "myProject"}
+			WithTimeouts(timeouts)(c)
+
+			AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
+			defer cancel()
+			_, err := c.GetSecret(ctx, "mySecret", "", "")
+			if err == nil {
+				t.Fatalf("GetSecret() succeeded, want an error")
+			}
+			if errors.Is(err, ErrTimeout) != wantTimeout {
+				t.Errorf("GetSecret() error = %v, want ErrTimeout %v", err, wantTimeout)
+			}
+			var secretErr *SecretError
+			if wantTimeout && (!errors.As(err, &secretErr) || secretErr.Code != codes.DeadlineExceeded) {
+				t.Errorf("GetSecret() error = %#v, want code DeadlineExceeded", err)
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("GetSecret() error = %v, want it to wrap %v", err, context.DeadlineExceeded)
+			}
+		}
+	}
+
+	t.Run("DefaultTimeout", timeoutTest(time.Hour, Timeouts{Read: 5 * time.Millisecond}, true))
+	t.Run("EarlierDeadline", timeoutTest(5*time.Millisecond, Timeouts{Read: time.Hour}, false))
+	t.Run("OtherClass", timeoutTest(5*time.Millisecond, Timeouts{Write: time.Millisecond, Admin: time.Millisecond}, false))
+}
+
+func TestTimeouts_Classes(t *testing.T) {
+	timeouts := Timeouts{Read: 1, Write: 2, Admin: 3}
+	for op, want := range map[string]time.Duration{
+		"AccessSecretVersion":  1,
+		"ListSecrets":          1,
+		"AddSecretVersion":     2,
+		"CreateSecret":         3,
+		"UpdateSecret":         3,
+		"DisableSecretVersion": 3,
+	} {
+		if got := timeouts.timeout(op); got != want {
+			t.Errorf("timeout(%v) = %v, want %v", op, got, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer and meter of the package
+const instrumentationName = "github.com/kioie/gcp-secret-manager"
+
+// Span attributes. Payloads are never recorded
+const (
+	projectAttribute    = attribute.Key("gsm.project")
+	secretAttribute     = attribute.Key("gsm.secret")
+	versionAttribute    = attribute.Key("gsm.version")
+	attemptsAttribute   = attribute.Key("gsm.attempts")
+	statusCodeAttribute = attribute.Key("rpc.grpc.status_code")
+)
+
+// WithTracerProvider sets the provider of the tracer that records a span for every call to Secret Manager.
+// Without it the global provider is used, see otel.SetTracerProvider
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *Client) {
+		c.tracer = tp.Tracer(instrumentationName)
+	}
+}
+
+// startSpan starts the span of a call to Secret Manager
+func (c *Client) startSpan(ctx context.Context, op string, secret SecretName, version string) (context.Context, trace.Span) {
+	tracer := c.tracer
+	if tracer == nil {
+		tracer = otel.GetTracerProvider().Tracer(instrumentationName)
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("rpc.system", "grpc"),
+		attribute.String("rpc.service", "google.cloud.secretmanager.v1.SecretManagerService"),
+		attribute.String("rpc.method", op),
+		projectAttribute.String(secret.Project),
+	}
+	if secret.Secret != "" {
+		attrs = append(attrs, secretAttribute.String(secret.Secret))
+	}
+	if version != "" {
+		attrs = append(attrs, versionAttribute.String(version))
+	}
+	return tracer.Start(ctx, "gsm."+op, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endSpan records the result of a call to Secret Manager and ends its span
+func endSpan(span trace.Span, err error) {
+	var secretErr *SecretError
+	if errors.As(err, &secretErr) {
+		span.SetAttributes(attemptsAttribute.Int(secretErr.Attempts))
+	}
+	span.SetAttributes(statusCodeAttribute.Int(int(errorCode(err))))
+	if err != nil {
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,141 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClient_Tracing(t *testing.T) {
+	newTracedClient := func() (*Client, *tracetest.SpanRecorder) {
+		recorder := tracetest.NewSpanRecorder()
+		c := &Client{smc: client, projectID: "myProject", noChecksums: true}
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))(c)
+		return c, recorder
+	}
+	attributes := func(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, kv := range span.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		return attrs
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		c, recorder := newTracedClient()
+		AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+			return &pb.AccessSecretVersionResponse{Name: req.Name, Payload: &pb.SecretPayload{Data: []byte("s3cr3t")}}, nil
+		}
+
+		if _, err := c.GetSecret(context.Background(), "mySecret", "", "3"); err != nil {
+			t.Fatalf("GetSecret() error = %v", err)
+		}
+
+		spans := recorder.Ended()
+		if len(spans) != 1 {
+			t.Fatalf("GetSecret() recorded %v spans, want 1", len(spans))
+		}
+		span := spans[0]
+		if span.Name() != "gsm.AccessSecretVersion" {
+			t.Errorf("span name = %v, want gsm.AccessSecretVersion", span.Name())
+		}
+		if span.Status().Code != otelcodes.Unset {
+			t.Errorf("span status = %v, want Unset", span.Status().Code)
+		}
+		attrs := attributes(span)
+		for key, want := range map[attribute.Key]attribute.Value{
+			projectAttribute:    attribute.StringValue("myProject"),
+			secretAttribute:     attribute.StringValue("mySecret"),
+			versionAttribute:    attribute.StringValue("3"),
+			statusCodeAttribute: attribute.IntValue(int(codes.OK)),
+		} {
+			if attrs[key] != want {
+				t.Errorf("span attribute %v = %v, want %v", key, attrs[key].Emit(), want.Emit())
+			}
+		}
+		for key, value := range attrs {
+			if strings.Contains(value.Emit(), "s3cr3t") {
+				t.Errorf("span attribute %v records the payload", key)
+			}
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		c, recorder := newTracedClient()
+		DestroySecretVersionFunc = func(ctx context.Context, req *pb.DestroySecretVersionRequest) (*pb.SecretVersion, error) {
+			return nil, status.Error(codes.PermissionDenied, "denied")
+		}
+
+		if _, err := c.DeleteSecretVersion(context.Background(), "mySecret", "", "2"); err == nil {
+			t.Fatal("DeleteSecretVersion() error = nil, want an error")
+		}
+
+		spans := recorder.Ended()
+		if len(spans) != 1 {
+			t.Fatalf("DeleteSecretVersion() recorded %v spans, want 1", len(spans))
+		}
+		span := spans[0]
+		if span.Name() != "gsm.DestroySecretVersion" {
+			t.Errorf("span name = %v, want gsm.DestroySecretVersion", span.Name())
+		}
+		if span.Status().Code != otelcodes.Error {
+			t.Errorf("span status = %v, want Error", span.Status().Code)
+		}
+		if got := attributes(span)[statusCodeAttribute]; got != attribute.IntValue(int(codes.PermissionDenied)) {
+			t.Errorf("span attribute %v = %v, want %v", statusCodeAttribute, got.Emit(), int(codes.PermissionDenied))
+		}
+	})
+
+	t.Run("AddPayloadNotRecorded", func(t *testing.T) {
+		c, recorder := newTracedClient()
+		AddSecretVersionFunc = func(ctx context.Context, req *pb.AddSecretVersionRequest) (*pb.SecretVersion, error) {
+			return &pb.SecretVersion{Name: req.Parent + "/versions/4"}, nil
+		}
+
+		if _, err := c.AddNewSecretVersion(context.Background(), "mySecret", "", []byte("s3cr3t")); err != nil {
+			t.Fatalf("AddNewSecretVersion() error = %v", err)
+		}
+
+		for _, span := range recorder.Ended() {
+			for key, value := range attributes(span) {
+				if strings.Contains(value.Emit(), "s3cr3t") {
+					t.Errorf("span %v attribute %v records the payload", span.Name(), key)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,140 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// maxEtagAttempts is how many times the label helpers read and write a secret that keeps changing concurrently
+const maxEtagAttempts = 5
+
+// UpdateSecret changes the fields of a secret set by opts. Only those fields are sent in the update mask,
+// so other fields keep their current values
+func (c *Client) UpdateSecret(ctx context.Context, name SecretName, opts ...SecretOption) (*pb.Secret, error) {
+	name, err := c.secretName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := newSecretSpec(opts)
+	if spec.err != nil {
+		return nil, spec.err
+	}
+	if spec.secret.Replication != nil {
+		return nil, fmt.Errorf("%w: the replication of a secret cannot be changed", ErrInvalidOption)
+	}
+	if len(spec.paths) == 0 {
+		return nil, ErrNothingToUpdate
+	}
+	spec.secret.Name = name.String()
+
+	updateSecretReq := pb.UpdateSecretRequest{
+		Secret:     spec.secret,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: spec.paths},
+	}
+
+	var secret *pb.Secret
+	err = c.call(ctx, "UpdateSecret", name, "", func(ctx context.Context) (err error) {
+		secret, err = c.smc.UpdateSecret(ctx, &updateSecretReq)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// SetLabel sets one label of a secret, keeping its other labels
+func (c *Client) SetLabel(ctx context.Context, name SecretName, key string, value string) (*pb.Secret, error) {
+	return c.MergeLabels(ctx, name, map[string]string{key: value})
+}
+
+// RemoveLabel removes one label of a secret, keeping its other labels
+func (c *Client) RemoveLabel(ctx context.Context, name SecretName, key string) (*pb.Secret, error) {
+	return c.modifyLabels(ctx, name, func(labels map[string]string) {
+		delete(labels, key)
+	})
+}
+
+// MergeLabels sets the given labels of a secret, keeping its other labels
+func (c *Client) MergeLabels(ctx context.Context, name SecretName, labels map[string]string) (*pb.Secret, error) {
+	return c.modifyLabels(ctx, name, func(current map[string]string) {
+		for k, v := range labels {
+			current[k] = v
+		}
+	})
+}
+
+// modifyLabels reads the labels of a secret, applies modify and writes them back guarded by the etag that was read.
+// It starts over when the secret was changed in between
+func (c *Client) modifyLabels(ctx context.Context, name SecretName, modify func(labels map[string]string)) (*pb.Secret, error) {
+	var err error
+	for attempt := 0; attempt < maxEtagAttempts; attempt++ {
+		var secret *pb.Secret
+		secret, err = c.LookupSecret(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		labels := make(map[string]string, len(secret.Labels))
+		for k, v := range secret.Labels {
+			labels[k] = v
+		}
+		modify(labels)
+
+		secret, err = c.UpdateSecret(ctx, name, SecretLabels(labels), SecretEtag(secret.Etag))
+		if !isEtagMismatch(err) {
+			return secret, err
+		}
+	}
+	return nil, err
+}
+
+// isEtagMismatch reports whether an update failed because the secret changed since its etag was read
+func isEtagMismatch(err error) bool {
+	var secretErr *SecretError
+	if !errors.As(err, &secretErr) {
+		return false
+	}
+	switch secretErr.Code {
+	case codes.Aborted:
+		return true
+	case codes.FailedPrecondition:
+		return strings.Contains(strings.ToLower(status.Convert(secretErr.Err).Message()), "etag")
+	}
+	return false
+}
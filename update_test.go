@@ -0,0 +1,134 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClient_UpdateSecret(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+
+	var got *pb.UpdateSecretRequest
+	UpdateSecretFunc = func(ctx context.Context, req *pb.UpdateSecretRequest) (*pb.Secret, error) {
+		got = req
+		return req.Secret, nil
+	}
+
+	_, err := c.UpdateSecret(context.Background(), SecretName{Secret: "mySecret"},
+		SecretLabels(map[string]string{"env": "prod"}),
+		SecretAnnotations(map[string]string{"owner": "payments"}),
+		SecretEtag(`"abc"`),
+	)
+	if err != nil {
+		t.Fatalf("UpdateSecret() error = %v", err)
+	}
+	if got.Secret.Name != "projects/myProject/secrets/mySecret" || got.Secret.Etag != `"abc"` {
+		t.Errorf("UpdateSecret() secret = %v", got.Secret)
+	}
+	if want := []string{"labels", "annotations"}; !reflect.DeepEqual(got.UpdateMask.Paths, want) {
+		t.Errorf("UpdateSecret() mask = %v, want %v", got.UpdateMask.Paths, want)
+	}
+
+	if _, err := c.UpdateSecret(context.Background(), SecretName{Secret: "mySecret"}, SecretEtag(`"abc"`)); !errors.Is(err, ErrNothingToUpdate) {
+		t.Errorf("UpdateSecret() error = %v, want %v", err, ErrNothingToUpdate)
+	}
+}
+
+func TestClient_Labels(t *testing.T) {
+	labelsTest := func(update func(c *Client) (*pb.Secret, error), want map[string]string) func(t *testing.T) {
+		return func(t *testing.T) {
+			c := &Client{smc: client, projectID: "myProject"}
+			stored := &pb.Secret{Labels: map[string]string{"env": "dev", "team": "core"}, Etag: `"1"`}
+			conflicts := 1
+
+			GetSecretFunc = func(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+				return stored, nil
+			}
+			UpdateSecretFunc = func(ctx context.Context, req *pb.UpdateSecretRequest) (*pb.Secret, error) {
+				if req.Secret.Etag != stored.Etag {
+					return nil, status.Error(codes.Aborted, "etag mismatch")
+				}
+				if conflicts > 0 {
+					// another writer changes the secret between the read and the write
+					conflicts--
+					stored = &pb.Secret{Labels: map[string]string{"env": "dev", "team": "core", "other": "writer"}, Etag: `"2"`}
+					return nil, status.Error(codes.Aborted, "etag mismatch")
+				}
+				stored = &pb.Secret{Labels: req.Secret.Labels, Etag: `"3"`}
+				return stored, nil
+			}
+
+			got, err := update(c)
+			if err != nil {
+				t.Fatalf("update error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Labels, want) {
+				t.Errorf("labels = %v, want %v", got.Labels, want)
+			}
+		}
+	}
+
+	t.Run("SetLabel", labelsTest(func(c *Client) (*pb.Secret, error) {
+		return c.SetLabel(context.Background(), SecretName{Secret: "mySecret"}, "env", "prod")
+	}, map[string]string{"env": "prod", "team": "core", "other": "writer"}))
+
+	t.Run("RemoveLabel", labelsTest(func(c *Client) (*pb.Secret, error) {
+		return c.RemoveLabel(context.Background(), SecretName{Secret: "mySecret"}, "team")
+	}, map[string]string{"env": "dev", "other": "writer"}))
+
+	t.Run("MergeLabels", labelsTest(func(c *Client) (*pb.Secret, error) {
+		return c.MergeLabels(context.Background(), SecretName{Secret: "mySecret"}, map[string]string{"env": "prod", "tier": "1"})
+	}, map[string]string{"env": "prod", "team": "core", "tier": "1", "other": "writer"}))
+}
+
+func TestClient_LabelsConflict(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+
+	GetSecretFunc = func(ctx context.Context, req *pb.GetSecretRequest) (*pb.Secret, error) {
+		return &pb.Secret{Etag: `"1"`}, nil
+	}
+	updates := 0
+	UpdateSecretFunc = func(ctx context.Context, req *pb.UpdateSecretRequest) (*pb.Secret, error) {
+		updates++
+		return nil, status.Error(codes.FailedPrecondition, "The etag provided does not match")
+	}
+
+	if _, err := c.SetLabel(context.Background(), SecretName{Secret: "mySecret"}, "env", "prod"); !errors.Is(err, ErrFailedPrecondition) {
+		t.Errorf("SetLabel() error = %v, want %v", err, ErrFailedPrecondition)
+	}
+	if updates != maxEtagAttempts {
+		t.Errorf("SetLabel() made %v updates, want %v", updates, maxEtagAttempts)
+	}
+}
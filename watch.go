@@ -0,0 +1,202 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+)
+
+const (
+	defaultWatchInterval   = 30 * time.Second
+	defaultWatchMaxBackoff = 5 * time.Minute
+	defaultWatchJitter     = 0.1
+)
+
+// Change describes a new latest version of a watched secret
+type Change struct {
+	Secret SecretName
+	// OldVersion is the version number before the change, 0 for the first version seen
+	OldVersion int64
+	// NewVersion is the version number of the new latest version
+	NewVersion int64
+	// OldData is the data of OldVersion, nil for the first version seen
+	OldData []byte
+	// NewData is the data of NewVersion
+	NewData []byte
+}
+
+// WatchOption configures Watch
+type WatchOption func(*watchOptions)
+
+type watchOptions struct {
+	interval   time.Duration
+	maxBackoff time.Duration
+	jitter     float64
+	onError    func(error)
+}
+
+// WatchInterval sets how often the latest version is checked. The default is 30 seconds
+func WatchInterval(d time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.interval = d
+	}
+}
+
+// WatchMaxBackoff caps the time between checks while they keep failing. The default is 5 minutes
+func WatchMaxBackoff(d time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.maxBackoff = d
+	}
+}
+
+// WatchJitter randomly shortens or lengthens each wait by up to this fraction, so many watchers
+// don't poll in lockstep. The default is 0.1
+func WatchJitter(fraction float64) WatchOption {
+	return func(o *watchOptions) {
+		o.jitter = fraction
+	}
+}
+
+// WatchOnError sets a function called with the errors of failed checks. Watch keeps going after errors
+func WatchOnError(fn func(error)) WatchOption {
+	return func(o *watchOptions) {
+		o.onError = fn
+	}
+}
+
+func newWatchOptions(opts []WatchOption) watchOptions {
+	o := watchOptions{
+		interval:   defaultWatchInterval,
+		maxBackoff: defaultWatchMaxBackoff,
+		jitter:     defaultWatchJitter,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Watch polls the latest version of a secret and calls fn whenever it moves to a new version, until ctx is done.
+// fn is first called with the version current when Watch starts. Failed checks are retried with exponential backoff.
+// Watch returns ctx.Err()
+func (c *Client) Watch(ctx context.Context, name SecretName, fn func(Change), opts ...WatchOption) error {
+	o := newWatchOptions(opts)
+	name, err := c.secretName(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	w := &watcher{c: c, name: name, fn: fn}
+	delay := o.interval
+	for {
+		if err := w.check(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if o.onError != nil {
+				o.onError(err)
+			}
+			delay = min(2*delay, o.maxBackoff)
+		} else {
+			delay = o.interval
+		}
+
+		timer := time.NewTimer(jitter(delay, o.jitter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// jitter randomly changes d by up to fraction of it
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + fraction*(2*rand.Float64()-1)))
+}
+
+// watcher tracks the latest version of a secret and reports when it changes
+type watcher struct {
+	mu      sync.Mutex
+	c       *Client
+	name    SecretName
+	fn      func(Change)
+	version int64
+	data    []byte
+}
+
+// check reads the latest version and calls fn if it is not the version seen before
+func (w *watcher) check(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	latest, err := w.c.GetSecretVersion(ctx, w.name.Version(LatestVersion))
+	if err != nil {
+		return err
+	}
+	if latest.State != pb.SecretVersion_ENABLED {
+		return fmt.Errorf("gsm: latest version of %v is %v", w.name, latest.State)
+	}
+
+	name, err := ParseVersionName(latest.Name)
+	if err != nil {
+		return err
+	}
+	number, ok := name.Number()
+	if !ok {
+		return fmt.Errorf("%w: latest version %q has no number", ErrInvalidName, latest.Name)
+	}
+	if number == w.version {
+		return nil
+	}
+
+	result, err := w.c.AccessSecretVersion(ctx, w.name.Version(name.Version))
+	if err != nil {
+		return err
+	}
+
+	change := Change{
+		Secret:     w.name,
+		OldVersion: w.version,
+		NewVersion: number,
+		OldData:    w.data,
+		NewData:    result.GetPayload().GetData(),
+	}
+	w.version, w.data = change.NewVersion, change.NewData
+	w.fn(change)
+	return nil
+}
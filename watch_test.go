@@ -0,0 +1,146 @@
+/*
+ * // Licensed to the Apache Software Foundation (ASF) under one
+ * // or more contributor license agreements.  See the NOTICE file
+ * // distributed with this work for additional information
+ * // regarding copyright ownership.  The ASF licenses this file
+ * // to you under the Apache License, Version 2.0 (the
+ * // "License"); you may not use this file except in compliance
+ * // with the License.  You may obtain a copy of the License at
+ * //
+ * //   http://www.apache.org/licenses/LICENSE-2.0
+ * //
+ * // Unless required by applicable law or agreed to in writing,
+ * // software distributed under the License is distributed on an
+ * // "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * // KIND, either express or implied.  See the License for the
+ * // specific language governing permissions and limitations
+ * // under the License.
+ *
+ *
+ *
+ *
+ * author: Eddy Kioi
+ * project: gcp-secret-manager
+ * date: 15/06/2020, 14:17
+ */
+
+package gsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	pb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeLatest serves a latest version that tests move forward, with "data-<n>" as the data of version n
+type fakeLatest struct {
+	mu       sync.Mutex
+	version  int
+	err      error
+	polls    int
+	disabled bool
+}
+
+func (f *fakeLatest) set(version int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.version, f.err = version, err
+}
+
+func (f *fakeLatest) install() {
+	GetSecretVersionFunc = func(ctx context.Context, req *pb.GetSecretVersionRequest) (*pb.SecretVersion, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.polls++
+		if f.err != nil {
+			return nil, f.err
+		}
+		state := pb.SecretVersion_ENABLED
+		if f.disabled {
+			state = pb.SecretVersion_DISABLED
+		}
+		return &pb.SecretVersion{
+			Name:  fmt.Sprintf("projects/myProject/secrets/mySecret/versions/%d", f.version),
+			State: state,
+		}, nil
+	}
+	AccessSecretVersionFunc = func(ctx context.Context, req *pb.AccessSecretVersionRequest) (*pb.AccessSecretVersionResponse, error) {
+		name, _ := ParseVersionName(req.Name)
+		return &pb.AccessSecretVersionResponse{Name: req.Name, Payload: &pb.SecretPayload{Data: []byte("data-" + name.Version)}}, nil
+	}
+}
+
+func TestClient_Watch(t *testing.T) {
+	c := &Client{smc: client, projectID: "myProject"}
+	latest := &fakeLatest{version: 1}
+	latest.install()
+
+	changes := make(chan Change, 10)
+	errs := make(chan error, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- c.Watch(ctx, SecretName{Secret: "mySecret"}, func(change Change) {
+			changes <- change
+		}, WatchInterval(time.Millisecond), WatchMaxBackoff(4*time.Millisecond), WatchJitter(0), WatchOnError(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}))
+	}()
+
+	name := SecretName{Project: "myProject", Secret: "mySecret"}
+	if got, want := <-changes, (Change{Secret: name, NewVersion: 1, NewData: []byte("data-1")}); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("first change = %+v, want %+v", got, want)
+	}
+
+	latest.set(1, status.Error(codes.Unavailable, "unavailable"))
+	if err := <-errs; status.Code(errors.Unwrap(err)) != codes.Unavailable {
+		t.Errorf("error = %v, want Unavailable", err)
+	}
+
+	latest.set(2, nil)
+	if got, want := <-changes, (Change{Secret: name, OldVersion: 1, NewVersion: 2, OldData: []byte("data-1"), NewData: []byte("data-2")}); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("change = %+v, want %+v", got, want)
+	}
+
+	// polling an unchanged version reports nothing
+	latest.mu.Lock()
+	polls := latest.polls
+	latest.mu.Unlock()
+	for {
+		latest.mu.Lock()
+		n := latest.polls
+		latest.mu.Unlock()
+		if n >= polls+3 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Watch() error = %v, want %v", err, context.Canceled)
+	}
+	if len(changes) != 0 {
+		t.Errorf("got %v changes for an unchanged version", len(changes))
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for range 100 {
+		if d := jitter(time.Second, 0.1); d < 900*time.Millisecond || d > 1100*time.Millisecond {
+			t.Fatalf("jitter() = %v, want within 10%% of 1s", d)
+		}
+	}
+	if d := jitter(time.Second, 0); d != time.Second {
+		t.Errorf("jitter() = %v, want 1s without jitter", d)
+	}
+}